@@ -0,0 +1,308 @@
+package entities
+
+import (
+	"image/color"
+	"math/big"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Statistics an AccumulatorDef can compute
+const (
+	StatisticSum   = "sum"
+	StatisticMin   = "min"
+	StatisticMax   = "max"
+	StatisticCount = "count"
+	StatisticMean  = "mean"
+)
+
+// Accumulator consumes a stream of integers and periodically emits one
+// aggregate of the values that arrived since its last emission, so every
+// value is counted once. Sum and mean cover at most the last Window of
+// them. Nothing is emitted when nothing arrived. A number arriving on the
+// trigger port emits right away and resets.
+type Accumulator struct {
+	Position     GridPosition
+	Def          *AccumulatorDef
+	Facing       Direction
+	OutputDir    Direction
+	OverflowDir  Direction
+	RejectDir    Direction
+	TriggerDir   Direction
+	Window       int
+	Interval     int
+	Timer        int
+	InputBuffer  []*Number
+	OutputBuffer []*Number
+	RejectBuffer []*Number
+	MaxBuffer    int
+	Overflowed   bool
+
+	// Stream state since the last emission
+	values   []int
+	count    int
+	min, max int
+	seen     bool
+}
+
+func NewAccumulator(gridX, gridY int, def *AccumulatorDef, facing Direction) *Accumulator {
+	return &Accumulator{
+		Position:     GridPosition{X: gridX, Y: gridY},
+		Def:          def,
+		Facing:       facing,
+		OutputDir:    def.Ports.Output.Resolve(facing),
+		OverflowDir:  def.Ports.Overflow.Resolve(facing),
+		RejectDir:    def.Ports.Reject.Resolve(facing),
+		TriggerDir:   def.Ports.Trigger.Resolve(facing),
+		Window:       def.Window,
+		Interval:     def.Interval,
+		Timer:        0,
+		InputBuffer:  make([]*Number, 0),
+		OutputBuffer: make([]*Number, 0),
+		RejectBuffer: make([]*Number, 0),
+		MaxBuffer:    def.Buffer,
+	}
+}
+
+func (a *Accumulator) Update() {
+	// Take in everything that arrived; only whole numbers can be aggregated
+	for len(a.InputBuffer) > 0 {
+		number := a.InputBuffer[0]
+		if !number.IsInteger() {
+			if len(a.RejectBuffer) >= a.MaxBuffer {
+				break
+			}
+			a.RejectBuffer = append(a.RejectBuffer, number)
+		} else {
+			a.record(number.Value)
+		}
+		a.InputBuffer = a.InputBuffer[1:]
+	}
+
+	if len(a.OutputBuffer) >= a.MaxBuffer {
+		return
+	}
+	a.Timer++
+	if a.Timer >= a.Interval {
+		a.emit()
+		a.Reset()
+	}
+}
+
+func (a *Accumulator) record(value int) {
+	a.values = append(a.values, value)
+	if len(a.values) > a.Window {
+		a.values = a.values[len(a.values)-a.Window:]
+	}
+	a.count++
+	if !a.seen || value < a.min {
+		a.min = value
+	}
+	if !a.seen || value > a.max {
+		a.max = value
+	}
+	a.seen = true
+}
+
+// Value computes the current aggregate. It reports false when there is
+// nothing to report yet or the sum does not fit in a number.
+func (a *Accumulator) Value() (int, bool) {
+	value, ok := a.ExactValue()
+	if !ok || !value.IsInt64() {
+		return 0, false
+	}
+	return int(value.Int64()), true
+}
+
+// ExactValue computes the current aggregate in arbitrary precision. It
+// reports false when there is nothing to report yet.
+func (a *Accumulator) ExactValue() (*big.Int, bool) {
+	switch a.Def.Statistic {
+	case StatisticCount:
+		return big.NewInt(int64(a.count)), a.count > 0
+	case StatisticMin:
+		return big.NewInt(int64(a.min)), a.seen
+	case StatisticMax:
+		return big.NewInt(int64(a.max)), a.seen
+	}
+
+	if len(a.values) == 0 {
+		return nil, false
+	}
+	sum := new(big.Int)
+	for _, value := range a.values {
+		sum.Add(sum, big.NewInt(int64(value)))
+	}
+	if a.Def.Statistic == StatisticMean {
+		// Div rounds towards negative infinity for a positive divisor
+		sum.Div(sum, big.NewInt(int64(len(a.values))))
+	}
+	return sum, true
+}
+
+func (a *Accumulator) emit() {
+	value, ok := a.Value()
+	a.Overflowed = !ok && len(a.values) > 0
+	if !ok {
+		return
+	}
+	worldX, worldY := a.Position.ToWorldPos()
+	a.OutputBuffer = append(a.OutputBuffer, NewNumber(worldX+TileSize/2, worldY+TileSize/2, value))
+}
+
+// Trigger emits the current aggregate immediately, if there is room, and resets the stream
+func (a *Accumulator) Trigger() {
+	if len(a.OutputBuffer) < a.MaxBuffer {
+		a.emit()
+	}
+	a.Reset()
+}
+
+// Reset forgets every value seen so far
+func (a *Accumulator) Reset() {
+	a.values = a.values[:0]
+	a.count = 0
+	a.seen = false
+	a.Timer = 0
+}
+
+// WindowFill returns how many values the window currently holds
+func (a *Accumulator) WindowFill() int {
+	return len(a.values)
+}
+
+// AdjustWindow changes how many values sum and mean cover, keeping at least one
+func (a *Accumulator) AdjustWindow(delta int) {
+	a.Window = max(a.Window+delta, 1)
+	if len(a.values) > a.Window {
+		a.values = a.values[len(a.values)-a.Window:]
+	}
+}
+
+// ScaleWorkTime stretches the ticks between emissions
+func (a *Accumulator) ScaleWorkTime(scale func(ticks int) int) {
+	a.Interval = scale(a.Interval)
+}
+
+// AdjustInterval changes the ticks between emissions, keeping at least 10
+func (a *Accumulator) AdjustInterval(delta int) {
+	a.Interval = max(a.Interval+delta, 10)
+}
+
+func (a *Accumulator) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := a.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw accumulator base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, a.Def.RGBA(), false)
+
+	// Draw output, reject and trigger sides
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, a.OutputDir, color.RGBA{255, 200, 100, 255})
+	if a.Def.Ports.Overflow != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, a.OverflowDir, color.RGBA{180, 120, 255, 255})
+	}
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, a.RejectDir, color.RGBA{255, 80, 80, 255})
+	if a.Def.Ports.Trigger != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, a.TriggerDir, color.RGBA{100, 220, 255, 255})
+	}
+
+	// Draw time until the next emission
+	progress := float32(a.Timer) / float32(a.Interval)
+	progressColor := color.RGBA{255, 255, 100, 200}
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size*progress, size*0.1, progressColor, false)
+
+	// Draw statistic symbol if zoom is sufficient
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.White)
+		text.Draw(screen, a.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border
+	borderColor := color.RGBA{140, 220, 200, 255}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+func (a *Accumulator) CanAcceptInput(fromPos GridPosition) bool {
+	if a.IsTriggerFrom(fromPos) {
+		return true
+	}
+	if len(a.InputBuffer) >= a.MaxBuffer {
+		return false
+	}
+	for _, side := range a.Def.Ports.Inputs {
+		if a.Position.Neighbor(side.Resolve(a.Facing)) == fromPos {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Accumulator) AcceptNumber(number *Number) {
+	a.InputBuffer = append(a.InputBuffer, number)
+}
+
+// IsTriggerFrom reports whether numbers from fromPos arrive on the trigger port
+func (a *Accumulator) IsTriggerFrom(fromPos GridPosition) bool {
+	return a.Def.Ports.Trigger != "" && a.Position.Neighbor(a.TriggerDir) == fromPos
+}
+
+func (a *Accumulator) GetOutputPosition() GridPosition {
+	return a.Position.Neighbor(a.OutputDir)
+}
+
+// GetOverflowPosition returns the tile surplus goes to, if the accumulator has an overflow side
+func (a *Accumulator) GetOverflowPosition() (GridPosition, bool) {
+	return a.Position.Neighbor(a.OverflowDir), a.Def.Ports.Overflow != ""
+}
+
+func (a *Accumulator) GetRejectPosition() GridPosition {
+	return a.Position.Neighbor(a.RejectDir)
+}
+
+func (a *Accumulator) TryOutputNumber() *Number {
+	if len(a.OutputBuffer) > 0 {
+		number := a.OutputBuffer[0]
+		a.OutputBuffer = a.OutputBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (a *Accumulator) TryRejectNumber() *Number {
+	if len(a.RejectBuffer) > 0 {
+		number := a.RejectBuffer[0]
+		a.RejectBuffer = a.RejectBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (a *Accumulator) HasOutputReady() bool {
+	return len(a.OutputBuffer) > 0
+}
+
+func (a *Accumulator) HasRejectReady() bool {
+	return len(a.RejectBuffer) > 0
+}
+
+func (a *Accumulator) GetGridPosition() GridPosition {
+	return a.Position
+}
+
+func (a *Accumulator) GetSize() (int, int) {
+	return 1, 1
+}
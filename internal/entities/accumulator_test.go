@@ -0,0 +1,53 @@
+package entities
+
+import "testing"
+
+func newTestAccumulator(statistic string) *Accumulator {
+	def := &AccumulatorDef{
+		DefBase:   DefBase{ID: statistic},
+		Statistic: statistic,
+		Window:    10,
+		Interval:  10,
+		Buffer:    100,
+		Ports:     PortLayout{Inputs: []Side{SideBack}, Output: SideFront, Reject: SideRight},
+	}
+	return NewAccumulator(0, 0, def, DirectionRight)
+}
+
+// emitted runs the accumulator for ticks updates and returns the values it emitted
+func emitted(a *Accumulator, ticks int) []int {
+	for i := 0; i < ticks; i++ {
+		a.Update()
+	}
+	var values []int
+	for number := a.TryOutputNumber(); number != nil; number = a.TryOutputNumber() {
+		values = append(values, number.Value)
+	}
+	return values
+}
+
+func TestAccumulatorEmitsEachValueOnce(t *testing.T) {
+	for statistic, want := range map[string]int{
+		StatisticSum:   55,
+		StatisticMean:  5,
+		StatisticMin:   1,
+		StatisticMax:   10,
+		StatisticCount: 10,
+	} {
+		a := newTestAccumulator(statistic)
+		for value := 1; value <= 10; value++ {
+			a.AcceptNumber(NewNumber(0, 0, value))
+		}
+		if got := emitted(a, a.Interval); len(got) != 1 || got[0] != want {
+			t.Errorf("%s: first interval emitted %v, want [%d]", statistic, got, want)
+		}
+		if got := emitted(a, 5*a.Interval); len(got) != 0 {
+			t.Errorf("%s: emitted %v with no new input, want nothing", statistic, got)
+		}
+
+		a.AcceptNumber(NewNumber(0, 0, 20))
+		if got := emitted(a, a.Interval); len(got) != 1 || (statistic != StatisticCount && got[0] != 20) {
+			t.Errorf("%s: emitted %v after one new value, want only that value aggregated", statistic, got)
+		}
+	}
+}
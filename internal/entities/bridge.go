@@ -0,0 +1,127 @@
+package entities
+
+import (
+	"image/color"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Bridge carries numbers over terrain that would swallow them. It takes
+// numbers from behind and sets them down on the first tile past the
+// crossable tiles ahead, crossing at most Def.Span of them. Until the world
+// tells it what it can cross, it hands numbers to the tile in front.
+type Bridge struct {
+	Position  GridPosition
+	Def       *BridgeDef
+	Facing    Direction
+	Landing   GridPosition
+	Crossed   int
+	Buffer    []*Number
+	MaxBuffer int
+}
+
+func NewBridge(gridX, gridY int, def *BridgeDef, facing Direction) *Bridge {
+	position := GridPosition{X: gridX, Y: gridY}
+	return &Bridge{
+		Position:  position,
+		Def:       def,
+		Facing:    facing,
+		Landing:   position.Neighbor(facing),
+		Buffer:    make([]*Number, 0),
+		MaxBuffer: def.Buffer,
+	}
+}
+
+func (b *Bridge) Update() {
+	// Bridges hand numbers on as soon as they arrive
+}
+
+// SpanOver finds where the bridge lands. A bridge too short to reach the
+// far side lands on the tile in front, so its numbers fall in.
+func (b *Bridge) SpanOver(crossable func(pos GridPosition) bool) {
+	pos := b.Position.Neighbor(b.Facing)
+	for crossed := 0; crossed <= b.Def.Span; crossed++ {
+		if !crossable(pos) {
+			b.Landing, b.Crossed = pos, crossed
+			return
+		}
+		pos = pos.Neighbor(b.Facing)
+	}
+	b.Landing, b.Crossed = b.Position.Neighbor(b.Facing), 0
+}
+
+// ArrivalFrom returns the tile numbers reach the landing from: the last
+// tile crossed, or the bridge itself
+func (b *Bridge) ArrivalFrom() GridPosition {
+	return b.Landing.Neighbor(b.Facing.Opposite())
+}
+
+func (b *Bridge) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := b.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw the deck out to the landing
+	landingX, landingY := camera.WorldToScreen(b.Landing.ToWorldPos())
+	deckColor := color.RGBA{170, 140, 90, 220}
+	vector.StrokeLine(screen, float32(screenX)+size/2, float32(screenY)+size/2,
+		float32(landingX)+size/2, float32(landingY)+size/2, size/4, deckColor, false)
+
+	// Draw bridge base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, b.Def.RGBA(), false)
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, b.Facing, color.RGBA{255, 200, 100, 255})
+
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.White)
+		text.Draw(screen, b.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border
+	borderColor := color.RGBA{200, 170, 110, 255}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+func (b *Bridge) CanAcceptInput(fromPos GridPosition) bool {
+	return len(b.Buffer) < b.MaxBuffer && fromPos == b.Position.Neighbor(b.Facing.Opposite())
+}
+
+func (b *Bridge) AcceptNumber(number *Number) {
+	b.Buffer = append(b.Buffer, number)
+}
+
+func (b *Bridge) GetOutputPosition() GridPosition {
+	return b.Landing
+}
+
+func (b *Bridge) TryOutputNumber() *Number {
+	if len(b.Buffer) > 0 {
+		number := b.Buffer[0]
+		b.Buffer = b.Buffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (b *Bridge) HasOutputReady() bool {
+	return len(b.Buffer) > 0
+}
+
+func (b *Bridge) GetGridPosition() GridPosition {
+	return b.Position
+}
+
+func (b *Bridge) GetSize() (int, int) {
+	return 1, 1
+}
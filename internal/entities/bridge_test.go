@@ -0,0 +1,36 @@
+package entities
+
+import "testing"
+
+func TestBridgeLandsPastCrossableTiles(t *testing.T) {
+	def := &BridgeDef{DefBase: DefBase{ID: "bridge"}, Span: 3, Buffer: 1}
+	tests := map[string]struct {
+		rift        []int // x of rift tiles east of a bridge at x = 0
+		wantLanding int
+		wantCrossed int
+	}{
+		"no rift":         {nil, 1, 0},
+		"one tile":        {[]int{1}, 2, 1},
+		"full span":       {[]int{1, 2, 3}, 4, 3},
+		"too wide":        {[]int{1, 2, 3, 4}, 1, 0},
+		"rift further on": {[]int{2}, 1, 0},
+	}
+	for name, tt := range tests {
+		bridge := NewBridge(0, 0, def, DirectionRight)
+		bridge.SpanOver(func(pos GridPosition) bool {
+			for _, x := range tt.rift {
+				if pos == (GridPosition{X: x}) {
+					return true
+				}
+			}
+			return false
+		})
+		if bridge.Landing != (GridPosition{X: tt.wantLanding}) || bridge.Crossed != tt.wantCrossed {
+			t.Errorf("%s: lands on %v after %d tiles, want x = %d after %d",
+				name, bridge.Landing, bridge.Crossed, tt.wantLanding, tt.wantCrossed)
+		}
+		if from := bridge.ArrivalFrom(); from != (GridPosition{X: tt.wantLanding - 1}) {
+			t.Errorf("%s: ArrivalFrom() = %v, want x = %d", name, from, tt.wantLanding-1)
+		}
+	}
+}
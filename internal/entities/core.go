@@ -8,9 +8,16 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
+// StoredItem is an item held by the Core together with the tags its number
+// carried, so costs can be matched without classifying it again
+type StoredItem struct {
+	Item Item
+	Tags NumberTags
+}
+
 type Core struct {
 	Position        GridPosition
-	StoredNumbers   []int
+	StoredNumbers   []StoredItem
 	InputPositions  []GridPosition
 	ProcessingQueue []*Number
 }
@@ -32,7 +39,7 @@ func NewCore(gridX, gridY int) *Core {
 
 	return &Core{
 		Position:        pos,
-		StoredNumbers:   make([]int, 0),
+		StoredNumbers:   make([]StoredItem, 0),
 		InputPositions:  inputPositions,
 		ProcessingQueue: make([]*Number, 0),
 	}
@@ -54,7 +61,7 @@ func (c *Core) Update() {
 		distance := math.Sqrt(dx*dx + dy*dy)
 
 		if distance < 10 {
-			c.StoredNumbers = append(c.StoredNumbers, number.Value)
+			c.StoredNumbers = append(c.StoredNumbers, StoredItem{Item: number.Item(), Tags: number.Tags})
 			c.ProcessingQueue = append(c.ProcessingQueue[:i], c.ProcessingQueue[i+1:]...)
 		}
 	}
@@ -129,7 +136,85 @@ func (c *Core) GetStoredCount() int {
 	return len(c.StoredNumbers)
 }
 
+// GetStoredFractionCount returns how many stored numbers are not integers
+func (c *Core) GetStoredFractionCount() int {
+	count := 0
+	for _, stored := range c.StoredNumbers {
+		if stored.Item.IsScalar() && !stored.Item.Scalar().IsInteger() {
+			count++
+		}
+	}
+	return count
+}
+
+// GetStoredBundleCount returns how many stored items are pairs, tuples, vectors or sets
+func (c *Core) GetStoredBundleCount() int {
+	count := 0
+	for _, stored := range c.StoredNumbers {
+		if !stored.Item.IsScalar() {
+			count++
+		}
+	}
+	return count
+}
+
 func (c *Core) OccupiesPosition(pos GridPosition) bool {
 	return pos.X >= c.Position.X && pos.X < c.Position.X+2 &&
 		pos.Y >= c.Position.Y && pos.Y < c.Position.Y+2
 }
+
+// CanAfford reports whether the stored numbers cover the given costs
+func (c *Core) CanAfford(costs []CostDef) bool {
+	_, ok := c.matchCosts(costs)
+	return ok
+}
+
+// Spend removes the numbers paying for the given costs from storage
+func (c *Core) Spend(costs []CostDef) bool {
+	used, ok := c.matchCosts(costs)
+	if !ok {
+		return false
+	}
+
+	remaining := make([]StoredItem, 0, len(c.StoredNumbers)-len(used))
+	for i, value := range c.StoredNumbers {
+		if !used[i] {
+			remaining = append(remaining, value)
+		}
+	}
+	c.StoredNumbers = remaining
+	return true
+}
+
+// matchCosts picks stored numbers for each cost entry, specific kinds first
+func (c *Core) matchCosts(costs []CostDef) (map[int]bool, bool) {
+	ordered := make([]CostDef, 0, len(costs))
+	for _, cost := range costs {
+		if cost.Kind != "any" {
+			ordered = append(ordered, cost)
+		}
+	}
+	for _, cost := range costs {
+		if cost.Kind == "any" {
+			ordered = append(ordered, cost)
+		}
+	}
+
+	used := make(map[int]bool)
+	for _, cost := range ordered {
+		needed := cost.Count
+		for i, value := range c.StoredNumbers {
+			if needed == 0 {
+				break
+			}
+			if !used[i] && cost.Matches(value) {
+				used[i] = true
+				needed--
+			}
+		}
+		if needed > 0 {
+			return nil, false
+		}
+	}
+	return used, true
+}
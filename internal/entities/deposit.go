@@ -1,7 +1,6 @@
 package entities
 
 import (
-	"fmt"
 	"image/color"
 
 	"github.com/Sanjar0126/math-factory/internal/fonts"
@@ -16,8 +15,9 @@ type NumberDeposit struct {
 	NumberValue  int
 	IsInfinite   bool
 	RemainingOre int
-	DepositType  NumberType
+	Tags         NumberTags
 	IsMined      bool
+	IsFaded      bool // drawn dimmed to bring out the deposits around it
 }
 
 // NewNumberDeposit creates a new number deposit
@@ -32,7 +32,7 @@ func NewNumberDeposit(gridX, gridY int, value int, infinite bool) *NumberDeposit
 		NumberValue:  value,
 		IsInfinite:   infinite,
 		RemainingOre: remaining,
-		DepositType:  determineNumberType(value),
+		Tags:         ClassifyNumber(value),
 		IsMined:      false,
 	}
 }
@@ -53,22 +53,16 @@ func (d *NumberDeposit) Draw(screen *ebiten.Image, camera CameraInterface) {
 		return
 	}
 
-	// Choose colors based on number type and mining status
+	// Choose colors based on the highest priority tag and mining status
 	var bgColor, borderColor color.RGBA
 	if d.IsMined {
 		bgColor = color.RGBA{100, 100, 100, 200}
 		borderColor = color.RGBA{150, 150, 150, 255}
 	} else {
-		switch d.DepositType {
-		case TypePrime:
-			bgColor = color.RGBA{50, 150, 50, 255}
-			borderColor = color.RGBA{100, 255, 100, 255}
-		case TypeComposite:
-			bgColor = color.RGBA{150, 80, 50, 255}
-			borderColor = color.RGBA{255, 150, 100, 255}
-		default:
-			bgColor = color.RGBA{50, 50, 150, 255}
-			borderColor = color.RGBA{150, 150, 255, 255}
+		style := d.Tags.primaryStyle()
+		bgColor, borderColor = style.Background, style.Color
+		if d.IsFaded {
+			bgColor, borderColor = fade(bgColor), fade(borderColor)
 		}
 	}
 
@@ -85,12 +79,20 @@ func (d *NumberDeposit) Draw(screen *ebiten.Image, camera CameraInterface) {
 		textColor := color.RGBA{255, 255, 255, 1}
 		if d.IsMined {
 			textColor = color.RGBA{200, 200, 200, 255}
+		} else if d.IsFaded {
+			textColor = color.RGBA{120, 120, 120, 255}
 		}
 
+		drawValueLabel(screen, FormatValue(d.NumberValue), FormatCompact(d.NumberValue),
+			screenX+float64(size)/2, screenY+float64(size)/2, float64(size)-4, textColor)
+	}
+
+	// Draw the tag icon in the top left corner
+	if icon := d.Tags.Icon(); icon != "" && !d.IsMined && !d.IsFaded && zoom > 0.8 {
 		opts := &text.DrawOptions{}
-		opts.GeoM.Translate(screenX+4, screenY+20)
-		opts.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, fmt.Sprintf("%d", d.NumberValue), fonts.MplusNormalFont, opts)
+		opts.GeoM.Translate(screenX+3, screenY+12)
+		opts.ColorScale.ScaleWithColor(d.Tags.primaryStyle().Color)
+		text.Draw(screen, icon, fonts.MplusNormalFont, opts)
 	}
 
 	// Draw infinite symbol if infinite deposit
@@ -102,6 +104,11 @@ func (d *NumberDeposit) Draw(screen *ebiten.Image, camera CameraInterface) {
 	}
 }
 
+// fade darkens a color and makes it translucent
+func fade(c color.RGBA) color.RGBA {
+	return color.RGBA{c.R / 3, c.G / 3, c.B / 3, c.A / 2}
+}
+
 // Rest of methods...
 func (d *NumberDeposit) GetGridPosition() GridPosition {
 	return d.Position
@@ -120,11 +127,16 @@ func (d *NumberDeposit) SetMined(mined bool) {
 }
 
 func (d *NumberDeposit) Mine() (int, bool) {
+	return d.Extract(true)
+}
+
+// Extract mines one number, only drawing down the deposit if deplete is set
+func (d *NumberDeposit) Extract(deplete bool) (int, bool) {
 	if !d.CanBeMined() {
 		return 0, false
 	}
 
-	if !d.IsInfinite {
+	if deplete && !d.IsInfinite {
 		d.RemainingOre--
 	}
 
@@ -16,6 +16,22 @@ func (gp GridPosition) ToWorldPos() (float64, float64) {
 	return float64(gp.X * TileSize), float64(gp.Y * TileSize)
 }
 
+// Neighbor returns the adjacent grid position in the given direction
+func (gp GridPosition) Neighbor(dir Direction) GridPosition {
+	switch dir {
+	case DirectionUp:
+		return GridPosition{X: gp.X, Y: gp.Y - 1}
+	case DirectionRight:
+		return GridPosition{X: gp.X + 1, Y: gp.Y}
+	case DirectionDown:
+		return GridPosition{X: gp.X, Y: gp.Y + 1}
+	case DirectionLeft:
+		return GridPosition{X: gp.X - 1, Y: gp.Y}
+	default:
+		return gp
+	}
+}
+
 // WorldPosToGrid converts world coordinates to grid position
 func WorldPosToGrid(worldX, worldY float64) GridPosition {
 	return GridPosition{
@@ -36,3 +52,58 @@ type Entity interface {
 	GetGridPosition() GridPosition
 	GetSize() (int, int) // Size in grid cells
 }
+
+// NumberProducer is an entity that emits numbers through an output side
+type NumberProducer interface {
+	Entity
+	HasOutputReady() bool
+	TryOutputNumber() *Number
+	GetOutputPosition() GridPosition
+}
+
+// OverflowProducer is a NumberProducer that may divert output to a second
+// side while its output side is blocked
+type OverflowProducer interface {
+	GetOverflowPosition() (GridPosition, bool)
+}
+
+// RejectProducer is an entity with a secondary port for numbers it routes aside
+type RejectProducer interface {
+	HasRejectReady() bool
+	TryRejectNumber() *Number
+	GetRejectPosition() GridPosition
+}
+
+// NumberAcceptor is an entity that can take numbers from a neighbouring tile
+type NumberAcceptor interface {
+	CanAcceptInput(fromPos GridPosition) bool
+	AcceptNumber(number *Number)
+}
+
+// TriggerAcceptor is a NumberAcceptor with a trigger port. Numbers arriving
+// there are consumed as a signal instead of being accepted as input.
+type TriggerAcceptor interface {
+	NumberAcceptor
+	IsTriggerFrom(fromPos GridPosition) bool
+	Trigger()
+}
+
+// Worker is a building whose work takes a set number of ticks, which the
+// ground it is placed on can stretch
+type Worker interface {
+	ScaleWorkTime(scale func(ticks int) int)
+}
+
+// Seeded is a building with its own random stream, which the world derives
+// from its seed
+type Seeded interface {
+	SeedFrom(worldSeed int64)
+}
+
+// Spanner is a building that hands numbers past its neighbors, which the
+// world tells what tiles it can cross. Its numbers arrive at their tile as
+// if from ArrivalFrom.
+type Spanner interface {
+	SpanOver(crossable func(pos GridPosition) bool)
+	ArrivalFrom() GridPosition
+}
@@ -0,0 +1,137 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberBase selects how number labels are written
+type NumberBase int
+
+const (
+	BaseDecimal NumberBase = iota
+	BaseBinary
+	BaseHex
+)
+
+// LabelBase is the base used for every number and deposit label
+var LabelBase = BaseDecimal
+
+// Next returns the following label base, wrapping around
+func (b NumberBase) Next() NumberBase {
+	return (b + 1) % 3
+}
+
+// Name returns the display name of the base
+func (b NumberBase) Name() string {
+	switch b {
+	case BaseBinary:
+		return "Binary"
+	case BaseHex:
+		return "Hex"
+	default:
+		return "Decimal"
+	}
+}
+
+// radix returns the digit base and prefix of the label base
+func (b NumberBase) radix() (int, string) {
+	switch b {
+	case BaseBinary:
+		return 2, "0b"
+	case BaseHex:
+		return 16, "0x"
+	default:
+		return 10, ""
+	}
+}
+
+// FormatValue writes a value in the current label base
+func FormatValue(value int) string {
+	switch LabelBase {
+	case BaseBinary:
+		return fmt.Sprintf("%#b", value)
+	case BaseHex:
+		return fmt.Sprintf("%#x", value)
+	default:
+		return fmt.Sprintf("%d", value)
+	}
+}
+
+const (
+	// compactDigits is the longest run of digits labels show unabbreviated
+	compactDigits = 4
+	// summaryDigits is how many digits FormatSummary keeps from each end
+	summaryDigits = 6
+)
+
+// abbreviations are the decimal suffixes FormatCompact uses, by power of 1000
+var abbreviations = []string{"", "k", "M", "B", "T"}
+
+// FormatCompact writes a value in at most a few characters: decimal values
+// become 12.3k, 4.56M and 7.89e18, binary and hex values keep their leading
+// digits followed by an ellipsis
+func FormatCompact(value int) string {
+	return FormatBigCompact(big.NewInt(int64(value)))
+}
+
+// FormatBig writes an arbitrary precision value in the current label base
+func FormatBig(n *big.Int) string {
+	radix, prefix := LabelBase.radix()
+	if n.Sign() < 0 {
+		return "-" + prefix + new(big.Int).Abs(n).Text(radix)
+	}
+	return prefix + n.Text(radix)
+}
+
+// FormatBigCompact is FormatCompact for arbitrary precision values
+func FormatBigCompact(n *big.Int) string {
+	radix, prefix := LabelBase.radix()
+	digits := new(big.Int).Abs(n).Text(radix)
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+	}
+	if len(digits) <= compactDigits {
+		return FormatBig(n)
+	}
+	if radix != 10 {
+		return sign + prefix + digits[:compactDigits] + "…"
+	}
+
+	// Three significant digits, truncated so 99999 never reads as 100k
+	exponent := len(digits) - 1
+	if group := exponent / 3; group < len(abbreviations) {
+		whole := exponent%3 + 1
+		mantissa := strings.TrimRight(digits[whole:3], "0")
+		if mantissa != "" {
+			mantissa = "." + mantissa
+		}
+		return sign + digits[:whole] + mantissa + abbreviations[group]
+	}
+	mantissa := strings.TrimRight(digits[1:3], "0")
+	if mantissa != "" {
+		mantissa = "." + mantissa
+	}
+	return sign + digits[:1] + mantissa + "e" + strconv.Itoa(exponent)
+}
+
+// FormatSummary writes a value in full when it is short, and otherwise as
+// its leading and trailing digits with a digit count, e.g.
+// "123456...654321 (457 digits)". The summary is plain ASCII for the HUD.
+func FormatSummary(n *big.Int) string {
+	radix, prefix := LabelBase.radix()
+	digits := new(big.Int).Abs(n).Text(radix)
+	if len(digits) <= 3*summaryDigits {
+		return FormatBig(n)
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s%s...%s (%d digits)", sign, prefix,
+		digits[:summaryDigits], digits[len(digits)-summaryDigits:], len(digits))
+}
@@ -0,0 +1,274 @@
+package entities
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Generator emits the terms of a mathematical sequence or random draws
+// without a deposit, burning input numbers as fuel for each term
+type Generator struct {
+	Position     GridPosition
+	Def          *GeneratorDef
+	Facing       Direction
+	OutputDir    Direction
+	OverflowDir  Direction
+	StartIndex   int
+	NextIndex    int
+	Interval     int
+	Timer        int
+	FuelBuffer   []*Number
+	OutputBuffer []*Number
+	MaxBuffer    int
+	Exhausted    bool
+	Modules      ModuleSlots
+
+	// Random generators draw from their own stream, seeded from the world
+	// seed and the building's position so the same placement in the same
+	// world always reproduces the same numbers
+	Param int
+	Seed  int64
+	rng   *rand.Rand
+}
+
+func NewGenerator(gridX, gridY int, def *GeneratorDef, facing Direction) *Generator {
+	pos := GridPosition{X: gridX, Y: gridY}
+	generator := &Generator{
+		Position:     pos,
+		Def:          def,
+		Facing:       facing,
+		OutputDir:    def.Ports.Output.Resolve(facing),
+		OverflowDir:  def.Ports.Overflow.Resolve(facing),
+		StartIndex:   0,
+		NextIndex:    0,
+		Interval:     def.Interval,
+		Timer:        0,
+		FuelBuffer:   make([]*Number, 0),
+		OutputBuffer: make([]*Number, 0),
+		MaxBuffer:    def.Buffer,
+		Modules:      NewModuleSlots(def.ModuleSlots),
+	}
+
+	if def.IsRandom() {
+		generator.Param = def.Param.Default
+	}
+	generator.SeedFrom(0)
+	return generator
+}
+
+// saltGeneratorSeed keeps generator seeds apart from other draws of the
+// world seed on the same tile
+const saltGeneratorSeed uint64 = 0x67656e657261746f
+
+// PositionSeed derives the random seed of a building at a grid position in
+// the world with the given seed
+func PositionSeed(worldSeed int64, pos GridPosition) int64 {
+	return int64(nmath.HashRand{Seed: uint64(worldSeed)}.Uint64(pos.X, pos.Y, saltGeneratorSeed))
+}
+
+// SeedFrom restarts a random generator's stream from the world seed and
+// its position
+func (g *Generator) SeedFrom(worldSeed int64) {
+	if g.Def.IsRandom() {
+		g.Seed = PositionSeed(worldSeed, g.Position)
+		g.rng = rand.New(rand.NewSource(g.Seed))
+		g.NextIndex = 0
+	}
+}
+
+func (g *Generator) Update() {
+	if g.Exhausted || len(g.OutputBuffer) >= g.MaxBuffer {
+		return
+	}
+
+	if len(g.FuelBuffer) < g.Def.FuelPerTerm {
+		g.Timer = 0
+		return
+	}
+
+	g.Timer++
+	if g.Timer >= g.EffectiveInterval() {
+		g.Timer = 0
+		g.generate()
+	}
+}
+
+func (g *Generator) generate() {
+	value, ok := g.nextTerm()
+	if !ok {
+		// The sequence has outgrown the number size; stop instead of wrapping
+		g.Exhausted = true
+		return
+	}
+	g.NextIndex++
+
+	if !g.Modules.SkipDepletion() {
+		g.FuelBuffer = g.FuelBuffer[g.Def.FuelPerTerm:]
+	}
+
+	worldX, worldY := g.Position.ToWorldPos()
+	g.OutputBuffer = append(g.OutputBuffer, NewNumber(worldX+TileSize/2, worldY+TileSize/2, value))
+	if g.Modules.RollBonus() {
+		g.OutputBuffer = append(g.OutputBuffer, NewNumber(worldX+TileSize/2, worldY+TileSize/2, value))
+	}
+}
+
+// nextTerm returns the value at NextIndex, or the next random draw
+func (g *Generator) nextTerm() (int, bool) {
+	if g.Def.IsRandom() {
+		return g.Def.distribution.Sample(g.rng, g.Param), true
+	}
+	return g.Def.sequence.Term(g.NextIndex)
+}
+
+func (g *Generator) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := g.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw generator base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, g.Def.RGBA(), false)
+
+	// Draw output side
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, g.OutputDir, color.RGBA{255, 200, 100, 255})
+	if g.Def.Ports.Overflow != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, g.OverflowDir, color.RGBA{180, 120, 255, 255})
+	}
+
+	// Draw generation progress
+	progress := float32(g.Timer) / float32(g.EffectiveInterval())
+	if progress > 0 {
+		progressColor := color.RGBA{255, 255, 100, 200}
+		vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+			size*progress, size*0.1, progressColor, false)
+	}
+
+	// Draw sequence symbol if zoom is sufficient
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.White)
+		text.Draw(screen, g.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border, dimmed once the sequence is exhausted
+	borderColor := color.RGBA{220, 200, 140, 255}
+	if g.Exhausted {
+		borderColor = color.RGBA{120, 120, 120, 255}
+	}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+// EffectiveInterval returns the ticks per term after speed modules
+func (g *Generator) EffectiveInterval() int {
+	return g.Modules.ScaleInterval(g.Interval)
+}
+
+// AdjustParam changes the distribution parameter of a random generator
+func (g *Generator) AdjustParam(delta int) {
+	if !g.Def.IsRandom() {
+		return
+	}
+	g.Param += delta
+	if g.Param < g.Def.Param.Min {
+		g.Param = g.Def.Param.Min
+	}
+}
+
+// ResetStream restarts a random generator from its seed
+func (g *Generator) ResetStream() {
+	if g.Def.IsRandom() {
+		g.rng = rand.New(rand.NewSource(g.Seed))
+		g.NextIndex = 0
+	}
+}
+
+// AdjustStartIndex moves the first emitted index and restarts the sequence there
+func (g *Generator) AdjustStartIndex(delta int) {
+	g.StartIndex += delta
+	if g.StartIndex < 0 {
+		g.StartIndex = 0
+	}
+	g.NextIndex = g.StartIndex
+	g.Exhausted = false
+}
+
+// AdjustInterval changes the ticks between terms, keeping at least one tick
+func (g *Generator) AdjustInterval(delta int) {
+	g.Interval += delta
+	if g.Interval < 1 {
+		g.Interval = 1
+	}
+}
+
+// ScaleWorkTime rescales the ticks between terms
+func (g *Generator) ScaleWorkTime(scale func(ticks int) int) {
+	g.Interval = scale(g.Interval)
+}
+
+// SequenceName returns the display name of the emitted sequence or distribution
+func (g *Generator) SequenceName() string {
+	if g.Def.IsRandom() {
+		return g.Def.distribution.Name
+	}
+	return g.Def.sequence.Name
+}
+
+func (g *Generator) CanAcceptInput(fromPos GridPosition) bool {
+	if len(g.FuelBuffer) >= g.MaxBuffer {
+		return false
+	}
+	for _, side := range g.Def.Ports.Inputs {
+		if g.Position.Neighbor(side.Resolve(g.Facing)) == fromPos {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) AcceptNumber(number *Number) {
+	g.FuelBuffer = append(g.FuelBuffer, number)
+}
+
+func (g *Generator) GetOutputPosition() GridPosition {
+	return g.Position.Neighbor(g.OutputDir)
+}
+
+// GetOverflowPosition returns the tile surplus goes to, if the generator has an overflow side
+func (g *Generator) GetOverflowPosition() (GridPosition, bool) {
+	return g.Position.Neighbor(g.OverflowDir), g.Def.Ports.Overflow != ""
+}
+
+func (g *Generator) TryOutputNumber() *Number {
+	if len(g.OutputBuffer) > 0 {
+		number := g.OutputBuffer[0]
+		g.OutputBuffer = g.OutputBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (g *Generator) HasOutputReady() bool {
+	return len(g.OutputBuffer) > 0
+}
+
+func (g *Generator) GetGridPosition() GridPosition {
+	return g.Position
+}
+
+func (g *Generator) GetSize() (int, int) {
+	return 1, 1
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+// draws returns the first n random terms of a generator placed at (3, -4)
+// in the world with the given seed
+func draws(def *GeneratorDef, worldSeed int64, n int) []int {
+	generator := def.Build(3, -4, DirectionUp).(*Generator)
+	generator.SeedFrom(worldSeed)
+	terms := make([]int, n)
+	for i := range terms {
+		terms[i], _ = generator.nextTerm()
+	}
+	return terms
+}
+
+func TestRandomGeneratorFollowsWorldSeed(t *testing.T) {
+	registry, err := LoadRegistry("../../data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range registry.Generators {
+		if !def.IsRandom() {
+			continue
+		}
+		first, again := draws(def, 42, 20), draws(def, 42, 20)
+		if !slices.Equal(first, again) {
+			t.Errorf("%s: world seed 42 drew %v, then %v", def.ID, first, again)
+		}
+		if other := draws(def, 43, 20); slices.Equal(first, other) {
+			t.Errorf("%s: world seeds 42 and 43 both drew %v", def.ID, first)
+		}
+	}
+}
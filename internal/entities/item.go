@@ -0,0 +1,356 @@
+package entities
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+)
+
+// ItemKind is the shape of the value an item carries
+type ItemKind int
+
+const (
+	ItemScalar ItemKind = iota
+	ItemPair
+	ItemTuple
+	ItemVector
+	ItemSet
+	ItemPolynomial
+)
+
+// Name returns the display name of the kind
+func (k ItemKind) Name() string {
+	switch k {
+	case ItemPair:
+		return "pair"
+	case ItemTuple:
+		return "tuple"
+	case ItemVector:
+		return "vector"
+	case ItemSet:
+		return "set"
+	case ItemPolynomial:
+		return "polynomial"
+	default:
+		return "number"
+	}
+}
+
+// Item is the value carried by a Number: a single rational, or a bundle of
+// components for pairs, tuples, vectors and sets. Polynomials keep their
+// integer coefficients lowest degree first, as in nmath.Polynomial.
+type Item struct {
+	Kind       ItemKind
+	Components []nmath.Rational
+}
+
+// ScalarItem wraps a single value
+func ScalarItem(r nmath.Rational) Item {
+	return Item{Kind: ItemScalar, Components: []nmath.Rational{r}}
+}
+
+// NewSetItem bundles values into a set, sorted with duplicates removed
+func NewSetItem(values []nmath.Rational) Item {
+	sorted := append([]nmath.Rational(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return rationalLess(sorted[i], sorted[j]) })
+
+	set := make([]nmath.Rational, 0, len(sorted))
+	for _, value := range sorted {
+		if len(set) == 0 || set[len(set)-1] != value {
+			set = append(set, value)
+		}
+	}
+	return Item{Kind: ItemSet, Components: set}
+}
+
+// IsScalar reports whether the item is a single value
+func (i Item) IsScalar() bool {
+	return i.Kind == ItemScalar
+}
+
+// Scalar returns the value of a scalar item
+func (i Item) Scalar() nmath.Rational {
+	return i.Components[0]
+}
+
+// String writes the item as "7", "(3, 4)", "<1, 2>", "{2, 3, 5}" or "x^2-3x+2"
+func (i Item) String() string {
+	return i.format(FormatValue)
+}
+
+// CompactString writes the item like String with every value abbreviated
+// by FormatCompact
+func (i Item) CompactString() string {
+	return i.format(FormatCompact)
+}
+
+// format writes the item using formatValue for each integer
+func (i Item) format(formatValue func(int) string) string {
+	if i.Kind == ItemPolynomial {
+		return formatPolynomial(i.Components, formatValue)
+	}
+
+	parts := make([]string, len(i.Components))
+	for j, value := range i.Components {
+		parts[j] = formatRational(value, formatValue)
+	}
+	joined := strings.Join(parts, ", ")
+
+	switch i.Kind {
+	case ItemPair, ItemTuple:
+		return "(" + joined + ")"
+	case ItemVector:
+		return "<" + joined + ">"
+	case ItemSet:
+		return "{" + joined + "}"
+	default:
+		return joined
+	}
+}
+
+// formatPolynomial writes coefficients, stored lowest degree first, as a
+// polynomial with the highest term first
+func formatPolynomial(coefficients []nmath.Rational, formatValue func(int) string) string {
+	var b strings.Builder
+	for degree := len(coefficients) - 1; degree >= 0; degree-- {
+		c := coefficients[degree].Num
+		// Skip zero terms, unless the whole polynomial is zero
+		if c == 0 && (degree > 0 || b.Len() > 0) {
+			continue
+		}
+
+		magnitude := c
+		if c < 0 {
+			magnitude = -c
+			b.WriteString("-")
+		} else if b.Len() > 0 {
+			b.WriteString("+")
+		}
+		if magnitude != 1 || degree == 0 {
+			b.WriteString(formatValue(magnitude))
+		}
+		if degree > 0 {
+			b.WriteString("x")
+		}
+		if degree > 1 {
+			b.WriteString("^" + formatValue(degree))
+		}
+	}
+	return b.String()
+}
+
+// formatRational writes a value as one integer or a fraction of two
+func formatRational(r nmath.Rational, formatValue func(int) string) string {
+	if r.IsInteger() {
+		return formatValue(r.Num)
+	}
+	return formatValue(r.Num) + "/" + formatValue(r.Den)
+}
+
+// rationalLess orders rationals by value, falling back to the original
+// order when the cross products overflow
+func rationalLess(a, b nmath.Rational) bool {
+	diff, ok := a.Sub(b)
+	return ok && diff.Num < 0
+}
+
+func getItemColor(kind ItemKind) color.RGBA {
+	switch kind {
+	case ItemPair:
+		return color.RGBA{90, 170, 200, 255}
+	case ItemTuple:
+		return color.RGBA{70, 140, 170, 255}
+	case ItemVector:
+		return color.RGBA{200, 120, 170, 255}
+	case ItemPolynomial:
+		return color.RGBA{180, 150, 60, 255}
+	default:
+		return color.RGBA{120, 180, 120, 255}
+	}
+}
+
+// ItemResult is the ProcessResult of an operation on whole items
+type ItemResult struct {
+	Outputs []Item
+	Rejects []Item
+}
+
+// bundleOperation gathers scalar inputs into a bundle of the given kind.
+// Bundles cannot be nested, so bundle inputs are rejected.
+func bundleOperation(arity int, kind ItemKind) processorOperation {
+	return processorOperation{
+		Arity:   arity,
+		Rejects: true,
+		ApplyItems: func(inputs []Item, _ int) ItemResult {
+			values, ok := scalarsOf(inputs)
+			if !ok {
+				return ItemResult{Rejects: inputs}
+			}
+			if kind == ItemSet {
+				return ItemResult{Outputs: []Item{NewSetItem(values)}}
+			}
+			return ItemResult{Outputs: []Item{{Kind: kind, Components: values}}}
+		},
+	}
+}
+
+// unbundle splits a bundle back into its components, passing scalars through
+func unbundle(inputs []Item, _ int) ItemResult {
+	outputs := make([]Item, 0, len(inputs[0].Components))
+	for _, value := range inputs[0].Components {
+		outputs = append(outputs, ScalarItem(value))
+	}
+	return ItemResult{Outputs: outputs}
+}
+
+// checkPythagorean passes tuples (a, b, c) with a²+b²=c² in some order and
+// rejects everything else
+func checkPythagorean(inputs []Item, _ int) ItemResult {
+	tuple := inputs[0]
+	if tuple.Kind != ItemTuple || len(tuple.Components) != 3 {
+		return ItemResult{Rejects: inputs}
+	}
+
+	squares := make([]int, 3)
+	for i, value := range tuple.Components {
+		if !value.IsInteger() {
+			return ItemResult{Rejects: inputs}
+		}
+		square, ok := nmath.MulChecked(value.Num, value.Num)
+		if !ok {
+			return ItemResult{Rejects: inputs}
+		}
+		squares[i] = square
+	}
+	sort.Ints(squares)
+
+	sum, ok := nmath.AddChecked(squares[0], squares[1])
+	if !ok || sum != squares[2] || squares[0] == 0 {
+		return ItemResult{Rejects: inputs}
+	}
+	return ItemResult{Outputs: inputs}
+}
+
+// addVectors sums two vectors of the same length component by component
+func addVectors(inputs []Item, _ int) ItemResult {
+	a, b := inputs[0], inputs[1]
+	if a.Kind != ItemVector || b.Kind != ItemVector || len(a.Components) != len(b.Components) {
+		return ItemResult{Rejects: inputs}
+	}
+
+	sum := make([]nmath.Rational, len(a.Components))
+	for i := range sum {
+		value, ok := a.Components[i].Add(b.Components[i])
+		if !ok {
+			return ItemResult{Rejects: inputs}
+		}
+		sum[i] = value
+	}
+	return ItemResult{Outputs: []Item{{Kind: ItemVector, Components: sum}}}
+}
+
+// dotProduct multiplies two vectors of the same length into a scalar
+func dotProduct(inputs []Item, _ int) ItemResult {
+	a, b := inputs[0], inputs[1]
+	if a.Kind != ItemVector || b.Kind != ItemVector || len(a.Components) != len(b.Components) {
+		return ItemResult{Rejects: inputs}
+	}
+
+	total := nmath.Integer(0)
+	for i := range a.Components {
+		product, ok := a.Components[i].Mul(b.Components[i])
+		if ok {
+			total, ok = total.Add(product)
+		}
+		if !ok {
+			return ItemResult{Rejects: inputs}
+		}
+	}
+	return ItemResult{Outputs: []Item{ScalarItem(total)}}
+}
+
+// buildPolynomial assembles integer coefficients, highest degree first, into a polynomial
+func buildPolynomial(inputs []Item, _ int) ItemResult {
+	coefficients, ok := integersOf(inputs)
+	if !ok {
+		return ItemResult{Rejects: inputs}
+	}
+	return ItemResult{Outputs: []Item{polynomialItem(nmath.NewPolynomial(coefficients))}}
+}
+
+// evaluatePolynomial takes a polynomial and an integer x, in either order, and emits p(x)
+func evaluatePolynomial(inputs []Item, _ int) ItemResult {
+	poly, x := inputs[0], inputs[1]
+	if x.Kind == ItemPolynomial {
+		poly, x = x, poly
+	}
+	if poly.Kind != ItemPolynomial || !x.IsScalar() || !x.Scalar().IsInteger() {
+		return ItemResult{Rejects: inputs}
+	}
+
+	value, ok := polynomialOf(poly).Eval(x.Scalar().Num)
+	if !ok {
+		return ItemResult{Rejects: inputs}
+	}
+	return ItemResult{Outputs: []Item{ScalarItem(nmath.Integer(value))}}
+}
+
+// polynomialRoots emits the integer roots of a polynomial in ascending order.
+// Polynomials without integer roots are rejected.
+func polynomialRoots(inputs []Item, _ int) ItemResult {
+	if inputs[0].Kind != ItemPolynomial {
+		return ItemResult{Rejects: inputs}
+	}
+	roots, ok := polynomialOf(inputs[0]).IntegerRoots()
+	if !ok || len(roots) == 0 {
+		return ItemResult{Rejects: inputs}
+	}
+
+	outputs := make([]Item, len(roots))
+	for i, root := range roots {
+		outputs[i] = ScalarItem(nmath.Integer(root))
+	}
+	return ItemResult{Outputs: outputs}
+}
+
+func polynomialItem(p nmath.Polynomial) Item {
+	coefficients := make([]nmath.Rational, len(p))
+	for i, c := range p {
+		coefficients[i] = nmath.Integer(c)
+	}
+	return Item{Kind: ItemPolynomial, Components: coefficients}
+}
+
+func polynomialOf(item Item) nmath.Polynomial {
+	p := make(nmath.Polynomial, len(item.Components))
+	for i, c := range item.Components {
+		p[i] = c.Num
+	}
+	return p
+}
+
+// integersOf unwraps whole-number scalar items
+func integersOf(items []Item) ([]int, bool) {
+	values := make([]int, len(items))
+	for i, item := range items {
+		if !item.IsScalar() || !item.Scalar().IsInteger() {
+			return nil, false
+		}
+		values[i] = item.Scalar().Num
+	}
+	return values, true
+}
+
+// scalarsOf unwraps scalar items, failing if any item is a bundle
+func scalarsOf(items []Item) ([]nmath.Rational, bool) {
+	values := make([]nmath.Rational, len(items))
+	for i, item := range items {
+		if !item.IsScalar() {
+			return nil, false
+		}
+		values[i] = item.Scalar()
+	}
+	return values, true
+}
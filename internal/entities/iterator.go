@@ -0,0 +1,324 @@
+package entities
+
+import (
+	"image/color"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// IteratorMode selects which values an iterator emits
+type IteratorMode int
+
+const (
+	// IterateEmitEach emits every new value of the orbit
+	IterateEmitEach IteratorMode = iota
+	// IterateEmitFinal emits only the smallest member of the cycle the orbit ends in
+	IterateEmitFinal
+)
+
+// Name returns the display name of the mode
+func (m IteratorMode) Name() string {
+	if m == IterateEmitFinal {
+		return "final value"
+	}
+	return "each step"
+}
+
+// iterationRules are the built-in rules an IteratorDef can name
+var iterationRules = map[string]func(int) (int, error){
+	"collatz":          nmath.CollatzStep,
+	"digit_square_sum": nmath.DigitSquareSum,
+}
+
+// Iterator holds one number and repeatedly applies a rule to it until the
+// orbit reaches a fixed point or cycle. Numbers the rule cannot handle, and
+// in final mode numbers that hit the iteration limit, leave through the
+// reject port.
+type Iterator struct {
+	Position      GridPosition
+	Def           *IteratorDef
+	Facing        Direction
+	OutputDir     Direction
+	OverflowDir   Direction
+	RejectDir     Direction
+	Mode          IteratorMode
+	MaxIterations int
+	Formula       *nmath.Formula
+	InputBuffer   []*Number
+	OutputBuffer  []*Number
+	RejectBuffer  []*Number
+	MaxBuffer     int
+	Timer         int
+	StepTime      int
+	Modules       ModuleSlots
+
+	// State of the number being iterated
+	Held    bool
+	Current int
+	Steps   int
+	orbit   []int
+	seen    map[int]int // value -> position in orbit
+}
+
+func NewIterator(gridX, gridY int, def *IteratorDef, facing Direction) *Iterator {
+	return &Iterator{
+		Position:      GridPosition{X: gridX, Y: gridY},
+		Def:           def,
+		Facing:        facing,
+		OutputDir:     def.Ports.Output.Resolve(facing),
+		OverflowDir:   def.Ports.Overflow.Resolve(facing),
+		RejectDir:     def.Ports.Reject.Resolve(facing),
+		Mode:          IterateEmitEach,
+		MaxIterations: def.MaxIterations,
+		Formula:       def.formula,
+		InputBuffer:   make([]*Number, 0),
+		OutputBuffer:  make([]*Number, 0),
+		RejectBuffer:  make([]*Number, 0),
+		MaxBuffer:     def.Buffer,
+		Timer:         0,
+		StepTime:      def.StepTime,
+		Modules:       NewModuleSlots(def.ModuleSlots),
+	}
+}
+
+func (it *Iterator) Update() {
+	if len(it.OutputBuffer) >= it.MaxBuffer || len(it.RejectBuffer) >= it.MaxBuffer {
+		return
+	}
+
+	if !it.Held {
+		it.Timer = 0
+		if len(it.InputBuffer) > 0 {
+			number := it.InputBuffer[0]
+			it.InputBuffer = it.InputBuffer[1:]
+			// Rules only apply to whole numbers
+			if !number.IsInteger() {
+				it.RejectBuffer = append(it.RejectBuffer, number)
+				return
+			}
+			it.hold(number.Value)
+		}
+		return
+	}
+
+	it.Timer++
+	if it.Timer >= it.EffectiveStepTime() {
+		it.Timer = 0
+		it.step()
+	}
+}
+
+// hold starts a new orbit at value
+func (it *Iterator) hold(value int) {
+	it.Held = true
+	it.Current = value
+	it.Steps = 0
+	it.orbit = []int{value}
+	it.seen = map[int]int{value: 0}
+}
+
+func (it *Iterator) step() {
+	next, err := it.apply(it.Current)
+	if err != nil {
+		it.emit(&it.RejectBuffer, it.Current)
+		it.Held = false
+		return
+	}
+	it.Steps++
+
+	if start, repeated := it.seen[next]; repeated {
+		if it.Mode == IterateEmitFinal {
+			cycleMin := it.orbit[start]
+			for _, value := range it.orbit[start:] {
+				cycleMin = min(cycleMin, value)
+			}
+			it.emit(&it.OutputBuffer, cycleMin)
+			if it.Modules.RollBonus() {
+				it.emit(&it.OutputBuffer, cycleMin)
+			}
+		}
+		it.Held = false
+		return
+	}
+
+	it.seen[next] = len(it.orbit)
+	it.orbit = append(it.orbit, next)
+	it.Current = next
+	if it.Mode == IterateEmitEach {
+		it.emit(&it.OutputBuffer, next)
+	}
+
+	// In each-step mode the orbit has already left, so only final mode
+	// hands the unfinished value to the reject port
+	if it.Steps >= it.MaxIterations {
+		if it.Mode == IterateEmitFinal {
+			it.emit(&it.RejectBuffer, it.Current)
+		}
+		it.Held = false
+	}
+}
+
+// apply runs the iterator's rule once
+func (it *Iterator) apply(value int) (int, error) {
+	if it.Formula != nil {
+		return it.Formula.Eval(value)
+	}
+	return iterationRules[it.Def.Rule](value)
+}
+
+func (it *Iterator) emit(buffer *[]*Number, value int) {
+	worldX, worldY := it.Position.ToWorldPos()
+	*buffer = append(*buffer, NewNumber(worldX+TileSize/2, worldY+TileSize/2, value))
+}
+
+func (it *Iterator) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := it.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw iterator base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, it.Def.RGBA(), false)
+
+	// Draw output and reject sides
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, it.OutputDir, color.RGBA{255, 200, 100, 255})
+	if it.Def.Ports.Overflow != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, it.OverflowDir, color.RGBA{180, 120, 255, 255})
+	}
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, it.RejectDir, color.RGBA{255, 80, 80, 255})
+
+	// Draw iteration progress towards the guard
+	if it.Held {
+		progress := float32(it.Steps) / float32(it.MaxIterations)
+		progressColor := color.RGBA{255, 255, 100, 200}
+		vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+			size*progress, size*0.1, progressColor, false)
+	}
+
+	// Draw rule symbol if zoom is sufficient
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.White)
+		text.Draw(screen, it.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border
+	borderColor := color.RGBA{200, 160, 220, 255}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+// EffectiveStepTime returns the ticks per iteration after speed modules
+func (it *Iterator) EffectiveStepTime() int {
+	return it.Modules.ScaleInterval(it.StepTime)
+}
+
+// ScaleWorkTime rescales the ticks per iteration
+func (it *Iterator) ScaleWorkTime(scale func(ticks int) int) {
+	it.StepTime = scale(it.StepTime)
+}
+
+// ToggleMode switches between emitting every step and only the final value
+func (it *Iterator) ToggleMode() {
+	if it.Mode == IterateEmitEach {
+		it.Mode = IterateEmitFinal
+	} else {
+		it.Mode = IterateEmitEach
+	}
+}
+
+// AdjustMaxIterations changes the iteration guard, keeping at least one step
+func (it *Iterator) AdjustMaxIterations(delta int) {
+	it.MaxIterations += delta
+	if it.MaxIterations < 1 {
+		it.MaxIterations = 1
+	}
+}
+
+// SetFormula parses and installs a new formula for formula-rule iterators
+func (it *Iterator) SetFormula(source string) error {
+	formula, err := nmath.ParseFormula(source)
+	if err != nil {
+		return err
+	}
+	it.Formula = formula
+	return nil
+}
+
+// UsesFormula reports whether the iterator applies a user formula
+func (it *Iterator) UsesFormula() bool {
+	return it.Def.Rule == IterationRuleFormula
+}
+
+func (it *Iterator) CanAcceptInput(fromPos GridPosition) bool {
+	if len(it.InputBuffer) >= it.MaxBuffer {
+		return false
+	}
+	for _, side := range it.Def.Ports.Inputs {
+		if it.Position.Neighbor(side.Resolve(it.Facing)) == fromPos {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *Iterator) AcceptNumber(number *Number) {
+	it.InputBuffer = append(it.InputBuffer, number)
+}
+
+func (it *Iterator) GetOutputPosition() GridPosition {
+	return it.Position.Neighbor(it.OutputDir)
+}
+
+// GetOverflowPosition returns the tile surplus goes to, if the iterator has an overflow side
+func (it *Iterator) GetOverflowPosition() (GridPosition, bool) {
+	return it.Position.Neighbor(it.OverflowDir), it.Def.Ports.Overflow != ""
+}
+
+func (it *Iterator) GetRejectPosition() GridPosition {
+	return it.Position.Neighbor(it.RejectDir)
+}
+
+func (it *Iterator) TryOutputNumber() *Number {
+	if len(it.OutputBuffer) > 0 {
+		number := it.OutputBuffer[0]
+		it.OutputBuffer = it.OutputBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (it *Iterator) TryRejectNumber() *Number {
+	if len(it.RejectBuffer) > 0 {
+		number := it.RejectBuffer[0]
+		it.RejectBuffer = it.RejectBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (it *Iterator) HasOutputReady() bool {
+	return len(it.OutputBuffer) > 0
+}
+
+func (it *Iterator) HasRejectReady() bool {
+	return len(it.RejectBuffer) > 0
+}
+
+func (it *Iterator) GetGridPosition() GridPosition {
+	return it.Position
+}
+
+func (it *Iterator) GetSize() (int, int) {
+	return 1, 1
+}
@@ -15,6 +15,7 @@ type Miner struct {
 	OutputDir      Direction
 	OutputBuffer   []*Number
 	MaxBuffer      int
+	Modules        ModuleSlots
 }
 
 type Direction int
@@ -26,6 +27,16 @@ const (
 	DirectionLeft
 )
 
+// RotateClockwise returns the direction a quarter turn clockwise
+func (d Direction) RotateClockwise() Direction {
+	return (d + 1) % 4
+}
+
+// Opposite returns the reverse direction
+func (d Direction) Opposite() Direction {
+	return (d + 2) % 4
+}
+
 func NewMiner(gridX, gridY int, deposit *NumberDeposit, outputDir Direction) *Miner {
 	return &Miner{
 		Position:       GridPosition{X: gridX, Y: gridY},
@@ -35,6 +46,7 @@ func NewMiner(gridX, gridY int, deposit *NumberDeposit, outputDir Direction) *Mi
 		OutputDir:      outputDir,
 		OutputBuffer:   make([]*Number, 0),
 		MaxBuffer:      5,
+		Modules:        NewModuleSlots(2),
 	}
 }
 
@@ -48,7 +60,7 @@ func (m *Miner) Update() {
 	}
 
 	m.MiningTimer++
-	if m.MiningTimer >= m.MiningInterval {
+	if m.MiningTimer >= m.EffectiveInterval() {
 		m.MiningTimer = 0
 		m.mine()
 	}
@@ -81,7 +93,7 @@ func (m *Miner) Draw(screen *ebiten.Image, camera CameraInterface) {
 	m.drawOutputIndicator(screen, float32(screenX), float32(screenY), size)
 
 	// Draw mining progress
-	progress := float32(m.MiningTimer) / float32(m.MiningInterval)
+	progress := float32(m.MiningTimer) / float32(m.EffectiveInterval())
 	if progress > 0 {
 		progressColor := color.RGBA{255, 255, 100, 200}
 		progressHeight := size * 0.1
@@ -97,13 +109,16 @@ func (m *Miner) Draw(screen *ebiten.Image, camera CameraInterface) {
 }
 
 func (m *Miner) drawOutputIndicator(screen *ebiten.Image, x, y, size float32) {
+	drawDirectionIndicator(screen, x, y, size, m.OutputDir, color.RGBA{255, 200, 100, 255})
+}
+
+// drawDirectionIndicator marks the side of a tile facing dir
+func drawDirectionIndicator(screen *ebiten.Image, x, y, size float32, dir Direction, indicatorColor color.RGBA) {
 	centerX := x + size/2
 	centerY := y + size/2
 	arrowSize := size * 0.15
 
-	indicatorColor := color.RGBA{255, 200, 100, 255}
-
-	switch m.OutputDir {
+	switch dir {
 	case DirectionUp:
 		vector.DrawFilledRect(screen, centerX-arrowSize/2, y, arrowSize, arrowSize, indicatorColor, false)
 	case DirectionRight:
@@ -116,26 +131,31 @@ func (m *Miner) drawOutputIndicator(screen *ebiten.Image, x, y, size float32) {
 }
 
 func (m *Miner) mine() {
-	if value, success := m.Deposit.Mine(); success {
+	deplete := !m.Modules.SkipDepletion()
+	if value, success := m.Deposit.Extract(deplete); success {
 		worldX, worldY := m.Position.ToWorldPos()
 		number := NewNumber(worldX+TileSize/2, worldY+TileSize/2, value)
 		m.OutputBuffer = append(m.OutputBuffer, number)
+
+		if m.Modules.RollBonus() {
+			bonus := NewNumber(worldX+TileSize/2, worldY+TileSize/2, value)
+			m.OutputBuffer = append(m.OutputBuffer, bonus)
+		}
 	}
 }
 
+// EffectiveInterval returns the mining interval after speed modules
+func (m *Miner) EffectiveInterval() int {
+	return m.Modules.ScaleInterval(m.MiningInterval)
+}
+
+// ScaleWorkTime rescales the mining interval
+func (m *Miner) ScaleWorkTime(scale func(ticks int) int) {
+	m.MiningInterval = scale(m.MiningInterval)
+}
+
 func (m *Miner) GetOutputPosition() GridPosition {
-	switch m.OutputDir {
-	case DirectionUp:
-		return GridPosition{X: m.Position.X, Y: m.Position.Y - 1}
-	case DirectionRight:
-		return GridPosition{X: m.Position.X + 1, Y: m.Position.Y}
-	case DirectionDown:
-		return GridPosition{X: m.Position.X, Y: m.Position.Y + 1}
-	case DirectionLeft:
-		return GridPosition{X: m.Position.X - 1, Y: m.Position.Y}
-	default:
-		return m.Position
-	}
+	return m.Position.Neighbor(m.OutputDir)
 }
 
 func (m *Miner) TryOutputNumber() *Number {
@@ -0,0 +1,164 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModuleEffect is the building stat a module changes
+type ModuleEffect string
+
+const (
+	ModuleSpeed        ModuleEffect = "speed"
+	ModuleProductivity ModuleEffect = "productivity"
+	ModuleConservation ModuleEffect = "conservation"
+)
+
+// Building kinds a module can be slotted into
+const (
+	FitsMiner     = "miner"
+	FitsProcessor = "processor"
+	FitsGenerator = "generator"
+	FitsIterator  = "iterator"
+)
+
+// maxConservation caps how much depletion conservation modules can prevent
+const maxConservation = 0.8
+
+// ModuleDef is a module definition loaded from the data directory
+type ModuleDef struct {
+	ID     string       `json:"id"`
+	Name   string       `json:"name"`
+	Effect ModuleEffect `json:"effect"`
+	Amount float64      `json:"amount"`
+	Fits   []string     `json:"fits"`
+	Cost   []CostDef    `json:"cost"`
+}
+
+func (d *ModuleDef) id() string {
+	return d.ID
+}
+
+// FitsIn reports whether the module can be slotted into the given building kind
+func (d *ModuleDef) FitsIn(kind string) bool {
+	for _, fit := range d.Fits {
+		if fit == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleStats is the combined effect of the modules in a building.
+// Speed and productivity bonuses add up; conservation adds up but is capped at 80%.
+// Conservation is the share of cycles that neither deplete the deposit a
+// miner works nor burn a generator's fuel. Modules do not change what a
+// building costs to place.
+type ModuleStats struct {
+	Speed        float64
+	Productivity float64
+	Conservation float64
+}
+
+func (s ModuleStats) String() string {
+	return fmt.Sprintf("Speed +%.0f%%, Productivity +%.0f%%, Depletion -%.0f%%",
+		s.Speed*100, s.Productivity*100, s.Conservation*100)
+}
+
+// ModuleSlots holds the modules inserted into a building
+type ModuleSlots struct {
+	Modules  []*ModuleDef
+	Capacity int
+
+	// Fractional progress towards the next bonus output / skipped depletion.
+	// Accumulating instead of rolling dice keeps module output deterministic.
+	bonusProgress        float64
+	conservationProgress float64
+}
+
+func NewModuleSlots(capacity int) ModuleSlots {
+	return ModuleSlots{
+		Modules:  make([]*ModuleDef, 0, capacity),
+		Capacity: capacity,
+	}
+}
+
+// Insert adds a module if there is a free slot
+func (s *ModuleSlots) Insert(def *ModuleDef) bool {
+	if len(s.Modules) >= s.Capacity {
+		return false
+	}
+	s.Modules = append(s.Modules, def)
+	return true
+}
+
+// Remove takes out the most recently inserted module
+func (s *ModuleSlots) Remove() *ModuleDef {
+	if len(s.Modules) == 0 {
+		return nil
+	}
+	def := s.Modules[len(s.Modules)-1]
+	s.Modules = s.Modules[:len(s.Modules)-1]
+	return def
+}
+
+// Stats sums the effects of all inserted modules
+func (s *ModuleSlots) Stats() ModuleStats {
+	stats := ModuleStats{}
+	for _, def := range s.Modules {
+		switch def.Effect {
+		case ModuleSpeed:
+			stats.Speed += def.Amount
+		case ModuleProductivity:
+			stats.Productivity += def.Amount
+		case ModuleConservation:
+			stats.Conservation += def.Amount
+		}
+	}
+	if stats.Conservation > maxConservation {
+		stats.Conservation = maxConservation
+	}
+	return stats
+}
+
+// ScaleInterval shortens a base interval in ticks by the speed bonus
+func (s *ModuleSlots) ScaleInterval(base int) int {
+	interval := int(float64(base) / (1 + s.Stats().Speed))
+	if interval < 1 {
+		interval = 1
+	}
+	return interval
+}
+
+// RollBonus advances productivity and reports whether a bonus output is due
+func (s *ModuleSlots) RollBonus() bool {
+	s.bonusProgress += s.Stats().Productivity
+	if s.bonusProgress >= 1 {
+		s.bonusProgress--
+		return true
+	}
+	return false
+}
+
+// SkipDepletion advances conservation and reports whether this cycle
+// leaves the deposit or fuel untouched
+func (s *ModuleSlots) SkipDepletion() bool {
+	s.conservationProgress += s.Stats().Conservation
+	if s.conservationProgress >= 1 {
+		s.conservationProgress--
+		return true
+	}
+	return false
+}
+
+// Summary lists the inserted modules, e.g. "Speed I, Speed I (2/3)"
+func (s *ModuleSlots) Summary() string {
+	names := make([]string, len(s.Modules))
+	for i, def := range s.Modules {
+		names[i] = def.Name
+	}
+	if len(names) == 0 {
+		names = append(names, "none")
+	}
+	return fmt.Sprintf("%s (%d/%d)", strings.Join(names, ", "), len(s.Modules), s.Capacity)
+}
@@ -1,45 +1,114 @@
 package entities
 
 import (
-	"fmt"
 	"image/color"
 	"math"
 
 	"github.com/Sanjar0126/math-factory/internal/fonts"
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
-type NumberType int
-
-const (
-	TypeBasic NumberType = iota
-	TypePrime
-	TypeComposite
-)
-
+// Number is an item carried around the world. Integers have Denominator 1;
+// rationals keep Value/Denominator reduced with a positive denominator.
+// Bundles (pairs, tuples, vectors, sets) have a Kind other than ItemScalar
+// and keep their values in Components instead. Tags are only set on whole
+// numbers.
 type Number struct {
-	X, Y      float64
-	Value     int
-	Type      NumberType
-	VelocityX float64
-	VelocityY float64
-	Color     color.RGBA
-	IsMoving  bool
-	Size      float64
+	X, Y        float64
+	Value       int
+	Denominator int
+	Kind        ItemKind
+	Components  []nmath.Rational
+	Tags        NumberTags
+	VelocityX   float64
+	VelocityY   float64
+	Color       color.RGBA
+	IsMoving    bool
+	Size        float64
+	Age         int // ticks spent floating in the world
 }
 
 func NewNumber(x, y float64, value int) *Number {
+	tags := ClassifyNumber(value)
+	return &Number{
+		X:           x,
+		Y:           y,
+		Value:       value,
+		Denominator: 1,
+		Tags:        tags,
+		Color:       getNumberColor(tags),
+		IsMoving:    false,
+		Size:        12,
+	}
+}
+
+// NewRationalNumber creates a number item from an exact rational value
+func NewRationalNumber(x, y float64, r nmath.Rational) *Number {
+	if r.IsInteger() {
+		return NewNumber(x, y, r.Num)
+	}
 	return &Number{
-		X:        x,
-		Y:        y,
-		Value:    value,
-		Type:     determineNumberType(value),
-		Color:    getNumberColor(value),
-		IsMoving: false,
-		Size:     12,
+		X:           x,
+		Y:           y,
+		Value:       r.Num,
+		Denominator: r.Den,
+		Color:       color.RGBA{255, 220, 100, 255},
+		IsMoving:    false,
+		Size:        12,
+	}
+}
+
+// NewItemNumber creates a number item carrying a scalar or a bundle
+func NewItemNumber(x, y float64, item Item) *Number {
+	if item.IsScalar() {
+		return NewRationalNumber(x, y, item.Scalar())
+	}
+	return &Number{
+		X:           x,
+		Y:           y,
+		Denominator: 1,
+		Kind:        item.Kind,
+		Components:  item.Components,
+		Color:       getItemColor(item.Kind),
+		IsMoving:    false,
+		Size:        12,
+	}
+}
+
+// Rational returns the exact value of a scalar number
+func (n *Number) Rational() nmath.Rational {
+	return nmath.Rational{Num: n.Value, Den: n.Denominator}
+}
+
+// Item returns the value the number carries
+func (n *Number) Item() Item {
+	if n.IsBundle() {
+		return Item{Kind: n.Kind, Components: n.Components}
 	}
+	return ScalarItem(n.Rational())
+}
+
+// IsBundle reports whether the number carries several values
+func (n *Number) IsBundle() bool {
+	return n.Kind != ItemScalar
+}
+
+// IsInteger reports whether the number is a single whole number
+func (n *Number) IsInteger() bool {
+	return !n.IsBundle() && n.Denominator == 1
+}
+
+// Label returns the full text of the number, e.g. "7", "1/7" or "(3, 4, 5)"
+func (n *Number) Label() string {
+	return n.Item().String()
+}
+
+// CompactLabel returns the label with large values abbreviated, e.g. "12.3k"
+func (n *Number) CompactLabel() string {
+	return n.Item().CompactString()
 }
 
 func (n *Number) Update() {
@@ -68,6 +137,11 @@ func (n *Number) Draw(screen *ebiten.Image, camera CameraInterface) {
 		return
 	}
 
+	if n.IsBundle() {
+		n.drawChip(screen, screenX, screenY, size, zoom)
+		return
+	}
+
 	// Draw circle
 	vector.DrawFilledCircle(screen, float32(screenX), float32(screenY), size/2, n.Color, false)
 
@@ -77,64 +151,66 @@ func (n *Number) Draw(screen *ebiten.Image, camera CameraInterface) {
 
 	// Draw number if zoom is sufficient
 	if zoom > 0.7 {
-		opts := &text.DrawOptions{}
-		opts.GeoM.Translate(screenX-6, screenY+4)
-		opts.ColorScale.ScaleWithColor(color.White)
-		text.Draw(screen, fmt.Sprintf("%d", n.Value), fonts.MplusNormalFont, opts)
+		drawValueLabel(screen, n.Label(), n.CompactLabel(), screenX, screenY, float64(size)*2, color.White)
 	}
 }
 
-func (n *Number) MoveTo(targetX, targetY, speed float64) {
-	dx := targetX - n.X
-	dy := targetY - n.Y
-	distance := math.Sqrt(dx*dx + dy*dy)
+// drawChip draws a bundle as a rectangle wide enough for a few components
+func (n *Number) drawChip(screen *ebiten.Image, screenX, screenY float64, size float32, zoom float64) {
+	width := size * 2.5
+	left := float32(screenX) - width/2
+	top := float32(screenY) - size/2
 
-	if distance > 0 {
-		n.VelocityX = (dx / distance) * speed
-		n.VelocityY = (dy / distance) * speed
-		n.IsMoving = true
+	vector.DrawFilledRect(screen, left, top, width, size, n.Color, false)
+	vector.StrokeRect(screen, left, top, width, size, 1, color.RGBA{255, 255, 255, 150}, false)
+
+	if zoom > 0.7 {
+		drawValueLabel(screen, n.Label(), n.CompactLabel(), screenX, screenY, float64(width), color.White)
 	}
 }
 
-func IsPrime(n int) bool {
-	return isPrime(n)
-}
+// minLabelScale is how far drawValueLabel shrinks a full label before
+// switching to the compact one
+const minLabelScale = 0.6
 
-func determineNumberType(value int) NumberType {
-	if value < 2 {
-		return TypeBasic
-	}
-	if isPrime(value) {
-		return TypePrime
+// drawValueLabel draws the full label if it stays readable within maxWidth
+// and the compact label otherwise, so zooming in reveals more digits
+func drawValueLabel(screen *ebiten.Image, full, compact string, x, y, maxWidth float64, clr color.Color) {
+	label := full
+	if width, _ := text.Measure(full, fonts.MplusNormalFont, 0); width*minLabelScale > maxWidth {
+		label = compact
 	}
-	return TypeComposite
+	drawCenteredLabel(screen, label, x, y, maxWidth, clr)
 }
 
-func isPrime(n int) bool {
-	if n < 2 {
-		return false
-	}
-	if n == 2 {
-		return true
+// drawCenteredLabel draws text centered on (x, y), shrinking it to fit
+// maxWidth so long and negative values stay readable
+func drawCenteredLabel(screen *ebiten.Image, label string, x, y, maxWidth float64, clr color.Color) {
+	opts := &text.DrawOptions{}
+	opts.PrimaryAlign = text.AlignCenter
+	opts.SecondaryAlign = text.AlignCenter
+	if width, _ := text.Measure(label, fonts.MplusNormalFont, 0); width > maxWidth {
+		scale := maxWidth / width
+		opts.GeoM.Scale(scale, scale)
 	}
-	if n%2 == 0 {
-		return false
-	}
-	for i := 3; i*i <= n; i += 2 {
-		if n%i == 0 {
-			return false
-		}
-	}
-	return true
+	opts.GeoM.Translate(x, y)
+	opts.ColorScale.ScaleWithColor(clr)
+	text.Draw(screen, label, fonts.MplusNormalFont, opts)
 }
 
-func getNumberColor(value int) color.RGBA {
-	switch determineNumberType(value) {
-	case TypePrime:
-		return color.RGBA{100, 255, 100, 255}
-	case TypeComposite:
-		return color.RGBA{255, 150, 100, 255}
-	default:
-		return color.RGBA{150, 150, 255, 255}
+func (n *Number) MoveTo(targetX, targetY, speed float64) {
+	dx := targetX - n.X
+	dy := targetY - n.Y
+	distance := math.Sqrt(dx*dx + dy*dy)
+
+	if distance > 0 {
+		n.VelocityX = (dx / distance) * speed
+		n.VelocityY = (dy / distance) * speed
+		n.IsMoving = true
 	}
 }
+
+// getNumberColor colors a whole number by its highest priority tag
+func getNumberColor(tags NumberTags) color.RGBA {
+	return tags.primaryStyle().Color
+}
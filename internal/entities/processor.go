@@ -1 +1,555 @@
-package entities
\ No newline at end of file
+package entities
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// ProcessResult holds the values a processor emits for one set of inputs.
+// Rejects leave through the secondary port, which each kind uses for the
+// inputs it routes aside.
+type ProcessResult struct {
+	Outputs []int
+	Rejects []int
+}
+
+// RationalResult is the ProcessResult of an operation on exact rationals
+type RationalResult struct {
+	Outputs []nmath.Rational
+	Rejects []nmath.Rational
+}
+
+// processorOperation is the code behind an operation named in a ProcessorDef.
+// Integer operations set Apply and only see whole numbers; fractions sent to
+// them are routed aside. Operations that understand fractions set ApplyRational,
+// and operations on pairs, tuples, vectors and sets set ApplyItems.
+// ParamArity operations take Param+1 inputs instead of a fixed Arity.
+// With ParamChoices the param picks one of the named choices.
+type processorOperation struct {
+	Arity         int
+	ParamArity    bool
+	UsesParam     bool
+	ParamChoices  []string
+	Rejects       bool
+	Apply         func(inputs []int, param int) ProcessResult
+	ApplyRational func(inputs []nmath.Rational, param int) RationalResult
+	ApplyItems    func(inputs []Item, param int) ItemResult
+}
+
+// rationalBinary builds a two-input rational operation that rejects its
+// inputs when the result is undefined or overflows. Whole inputs go through
+// the named registry operation instead, unless name is "".
+func rationalBinary(name string, apply func(a, b nmath.Rational) (nmath.Rational, bool)) processorOperation {
+	return processorOperation{
+		Arity:   2,
+		Rejects: true,
+		ApplyRational: func(inputs []nmath.Rational, _ int) RationalResult {
+			if result, ok := applyWhole(name, inputs); ok {
+				return result
+			}
+			r, ok := apply(inputs[0], inputs[1])
+			if !ok {
+				return RationalResult{Rejects: inputs}
+			}
+			return RationalResult{Outputs: []nmath.Rational{r}}
+		},
+	}
+}
+
+// rationalUnary builds a one-input rational operation that rejects its
+// input when the result overflows. Whole inputs go through the named
+// registry operation instead.
+func rationalUnary(name string, apply func(r nmath.Rational) (nmath.Rational, bool)) processorOperation {
+	return processorOperation{
+		Arity:   1,
+		Rejects: true,
+		ApplyRational: func(inputs []nmath.Rational, _ int) RationalResult {
+			if result, ok := applyWhole(name, inputs); ok {
+				return result
+			}
+			r, ok := apply(inputs[0])
+			if !ok {
+				return RationalResult{Rejects: inputs}
+			}
+			return RationalResult{Outputs: []nmath.Rational{r}}
+		},
+	}
+}
+
+// applyWhole runs a named registry operation when every input is a whole
+// number, reporting false otherwise so the rational path takes over.
+// Numbers hold ints, so results that need arbitrary precision are rejected
+// like any other overflow.
+func applyWhole(name string, inputs []nmath.Rational) (RationalResult, bool) {
+	if name == "" {
+		return RationalResult{}, false
+	}
+	args := make([]int, len(inputs))
+	for i, input := range inputs {
+		if !input.IsInteger() {
+			return RationalResult{}, false
+		}
+		args[i] = input.Num
+	}
+
+	result, err := nmath.ApplyInt(name, args...)
+	if err != nil {
+		return RationalResult{Rejects: inputs}, true
+	}
+	return RationalResult{Outputs: []nmath.Rational{nmath.Integer(result)}}, true
+}
+
+// registryOperation runs a named operation from the nmath registry. With
+// usesParam the param is passed as its last argument. Inputs outside the
+// operation's domain, and results that overflow, are rejected when rejects
+// is set and passed through otherwise.
+func registryOperation(name string, usesParam, rejects bool) processorOperation {
+	op, _ := nmath.OperationByName(name)
+	arity := op.Arity
+	if usesParam {
+		arity--
+	}
+	return processorOperation{
+		Arity:     arity,
+		UsesParam: usesParam,
+		Rejects:   rejects,
+		Apply: func(inputs []int, param int) ProcessResult {
+			args := inputs
+			if usesParam {
+				args = append(append([]int(nil), inputs...), param)
+			}
+			if result, err := nmath.ApplyInt(name, args...); err == nil {
+				return ProcessResult{Outputs: []int{result}}
+			}
+			if rejects {
+				return ProcessResult{Rejects: inputs}
+			}
+			return ProcessResult{Outputs: inputs}
+		},
+	}
+}
+
+// arithmeticFunctions are the choices of the "arithmetic" operation, each
+// naming its operation in the nmath registry
+var arithmeticFunctions = []struct {
+	Name      string
+	Operation string
+}{
+	{Name: "totient phi", Operation: "totient"},
+	{Name: "divisor count tau", Operation: "tau"},
+	{Name: "divisor sum sigma", Operation: "sigma"},
+	{Name: "Mobius mu", Operation: "mobius"},
+	{Name: "largest prime factor", Operation: "lpf"},
+}
+
+func arithmeticFunctionNames() []string {
+	names := make([]string, len(arithmeticFunctions))
+	for i, fn := range arithmeticFunctions {
+		names[i] = fn.Name
+	}
+	return names
+}
+
+var processorOperations = map[string]processorOperation{
+	"add":      rationalBinary("add", nmath.Rational.Add),
+	"subtract": rationalBinary("sub", nmath.Rational.Sub),
+	"multiply": rationalBinary("mul", nmath.Rational.Mul),
+	// The registry's div truncates, while dividing whole numbers here gives
+	// an exact fraction, so divide stays on the rational path
+	"divide": rationalBinary("", nmath.Rational.Div),
+	"negate": rationalUnary("neg", func(r nmath.Rational) (nmath.Rational, bool) {
+		return nmath.Integer(0).Sub(r)
+	}),
+	"abs": rationalUnary("abs", func(r nmath.Rational) (nmath.Rational, bool) {
+		if r.Num >= 0 {
+			return r, true
+		}
+		return nmath.Integer(0).Sub(r)
+	}),
+	"pair":        bundleOperation(2, ItemPair),
+	"triple":      bundleOperation(3, ItemTuple),
+	"vector":      bundleOperation(2, ItemVector),
+	"set":         bundleOperation(3, ItemSet),
+	"unbundle":    {Arity: 1, ApplyItems: unbundle},
+	"pythagorean": {Arity: 1, Rejects: true, ApplyItems: checkPythagorean},
+	"vector_add":  {Arity: 2, Rejects: true, ApplyItems: addVectors},
+	"dot":         {Arity: 2, Rejects: true, ApplyItems: dotProduct},
+	"polynomial":  {ParamArity: true, UsesParam: true, Rejects: true, ApplyItems: buildPolynomial},
+	"evaluate":    {Arity: 2, Rejects: true, ApplyItems: evaluatePolynomial},
+	"roots":       {Arity: 1, Rejects: true, ApplyItems: polynomialRoots},
+	"floor": {
+		Arity:   1,
+		Rejects: true,
+		ApplyRational: func(inputs []nmath.Rational, _ int) RationalResult {
+			// The whole part continues on, the fractional part goes aside
+			whole := nmath.Integer(inputs[0].Floor())
+			if inputs[0].IsInteger() {
+				return RationalResult{Outputs: []nmath.Rational{whole}}
+			}
+			frac, _ := inputs[0].Sub(whole)
+			return RationalResult{Outputs: []nmath.Rational{whole}, Rejects: []nmath.Rational{frac}}
+		},
+	},
+	"split": {
+		Arity: 1,
+		ApplyRational: func(inputs []nmath.Rational, _ int) RationalResult {
+			return RationalResult{Outputs: []nmath.Rational{
+				nmath.Integer(inputs[0].Num),
+				nmath.Integer(inputs[0].Den),
+			}}
+		},
+	},
+	"arithmetic": {
+		Arity:        1,
+		UsesParam:    true,
+		ParamChoices: arithmeticFunctionNames(),
+		Rejects:      true,
+		Apply: func(inputs []int, choice int) ProcessResult {
+			value, err := nmath.ApplyInt(arithmeticFunctions[choice].Operation, inputs[0])
+			if err != nil {
+				return ProcessResult{Rejects: inputs}
+			}
+			return ProcessResult{Outputs: []int{value}}
+		},
+	},
+	"and":        registryOperation("and", false, false),
+	"or":         registryOperation("or", false, false),
+	"xor":        registryOperation("xor", false, false),
+	"not":        registryOperation("not", true, true),
+	"shl":        registryOperation("shl", true, true),
+	"shr":        registryOperation("shr", true, false),
+	"mod":        registryOperation("mod", true, false),
+	"modpow":     registryOperation("modpow", true, true),
+	"modinverse": registryOperation("modinverse", true, true),
+	// Perfect powers leave as their root through the secondary port. Other
+	// numbers leave as one (floor root, leftover) pair, so inputs without a
+	// root, passed through unchanged, are told apart from results by kind.
+	"root": {
+		Arity:     1,
+		UsesParam: true,
+		Rejects:   true,
+		ApplyItems: func(inputs []Item, k int) ItemResult {
+			input := inputs[0]
+			if !input.IsScalar() || !input.Scalar().IsInteger() {
+				return ItemResult{Outputs: inputs}
+			}
+			n := input.Scalar().Num
+			r, exact, ok := nmath.IntRoot(n, k)
+			if !ok {
+				return ItemResult{Outputs: inputs}
+			}
+			if exact {
+				return ItemResult{Rejects: []Item{ScalarItem(nmath.Integer(r))}}
+			}
+			power := 1
+			for i := 0; i < k; i++ {
+				power *= r
+			}
+			pair := Item{Kind: ItemPair, Components: []nmath.Rational{nmath.Integer(r), nmath.Integer(n - power)}}
+			return ItemResult{Outputs: []Item{pair}}
+		},
+	},
+}
+
+// Processor consumes numbers from its neighbours and emits the result of an operation
+type Processor struct {
+	Position        GridPosition
+	Def             *ProcessorDef
+	Param           int
+	Facing          Direction
+	OutputDir       Direction
+	OverflowDir     Direction
+	RejectDir       Direction
+	InputBuffer     []*Number
+	OutputBuffer    []*Number
+	RejectBuffer    []*Number
+	ProcessingTimer int
+	ProcessingTime  int
+	MaxBuffer       int
+	Modules         ModuleSlots
+}
+
+func NewProcessor(gridX, gridY int, def *ProcessorDef, facing Direction) *Processor {
+	param := 0
+	if def.Param != nil {
+		param = def.Param.Default
+	}
+
+	return &Processor{
+		Position:        GridPosition{X: gridX, Y: gridY},
+		Def:             def,
+		Param:           param,
+		Facing:          facing,
+		OutputDir:       def.Ports.Output.Resolve(facing),
+		OverflowDir:     def.Ports.Overflow.Resolve(facing),
+		RejectDir:       def.Ports.Reject.Resolve(facing),
+		InputBuffer:     make([]*Number, 0),
+		OutputBuffer:    make([]*Number, 0),
+		RejectBuffer:    make([]*Number, 0),
+		ProcessingTimer: 0,
+		ProcessingTime:  def.ProcessingTime,
+		MaxBuffer:       def.Buffer,
+		Modules:         NewModuleSlots(def.ModuleSlots),
+	}
+}
+
+func (p *Processor) Update() {
+	if len(p.OutputBuffer) >= p.MaxBuffer || len(p.RejectBuffer) >= p.MaxBuffer {
+		return
+	}
+
+	if len(p.InputBuffer) < p.arity() {
+		p.ProcessingTimer = 0
+		return
+	}
+
+	p.ProcessingTimer++
+	if p.ProcessingTimer >= p.EffectiveProcessingTime() {
+		p.ProcessingTimer = 0
+		p.process()
+	}
+}
+
+// arity returns how many inputs one operation consumes
+func (p *Processor) arity() int {
+	if p.Def.op.ParamArity {
+		return p.Param + 1
+	}
+	return p.Def.op.Arity
+}
+
+func (p *Processor) process() {
+	arity := p.arity()
+	inputs := make([]Item, arity)
+	for i := range inputs {
+		inputs[i] = p.InputBuffer[i].Item()
+	}
+	p.InputBuffer = p.InputBuffer[arity:]
+
+	result := p.apply(inputs)
+	if p.Modules.RollBonus() {
+		result.Outputs = append(result.Outputs, result.Outputs...)
+	}
+
+	worldX, worldY := p.Position.ToWorldPos()
+	for _, item := range result.Outputs {
+		p.OutputBuffer = append(p.OutputBuffer, NewItemNumber(worldX+TileSize/2, worldY+TileSize/2, item))
+	}
+	for _, item := range result.Rejects {
+		p.RejectBuffer = append(p.RejectBuffer, NewItemNumber(worldX+TileSize/2, worldY+TileSize/2, item))
+	}
+}
+
+// apply runs the operation. Inputs an operation cannot handle, bundles for
+// scalar operations and fractions for integer ones, are routed around it:
+// they leave through the reject port, or pass through unchanged without one.
+func (p *Processor) apply(inputs []Item) ItemResult {
+	op := p.Def.op
+	if op.ApplyItems != nil {
+		return op.ApplyItems(inputs, p.Param)
+	}
+
+	scalars, ok := scalarsOf(inputs)
+	if !ok {
+		return p.routeAside(inputs)
+	}
+	if op.ApplyRational != nil {
+		result := op.ApplyRational(scalars, p.Param)
+		return ItemResult{Outputs: scalarItems(result.Outputs), Rejects: scalarItems(result.Rejects)}
+	}
+
+	values := make([]int, len(scalars))
+	for i, scalar := range scalars {
+		if !scalar.IsInteger() {
+			return p.routeAside(inputs)
+		}
+		values[i] = scalar.Num
+	}
+
+	result := op.Apply(values, p.Param)
+	var converted ItemResult
+	for _, value := range result.Outputs {
+		converted.Outputs = append(converted.Outputs, ScalarItem(nmath.Integer(value)))
+	}
+	for _, value := range result.Rejects {
+		converted.Rejects = append(converted.Rejects, ScalarItem(nmath.Integer(value)))
+	}
+	return converted
+}
+
+// routeAside sends unusable inputs to the reject port if there is one
+func (p *Processor) routeAside(inputs []Item) ItemResult {
+	if p.Def.Ports.Reject != "" {
+		return ItemResult{Rejects: inputs}
+	}
+	return ItemResult{Outputs: inputs}
+}
+
+// scalarItems wraps each value as a scalar item
+func scalarItems(values []nmath.Rational) []Item {
+	items := make([]Item, len(values))
+	for i, value := range values {
+		items[i] = ScalarItem(value)
+	}
+	return items
+}
+
+func (p *Processor) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := p.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw processor base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, p.Def.RGBA(), false)
+
+	// Draw output and reject sides
+	drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, p.OutputDir, color.RGBA{255, 200, 100, 255})
+	if p.Def.Ports.Overflow != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, p.OverflowDir, color.RGBA{180, 120, 255, 255})
+	}
+	if p.Def.Ports.Reject != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, p.RejectDir, color.RGBA{255, 80, 80, 255})
+	}
+
+	// Draw processing progress
+	progress := float32(p.ProcessingTimer) / float32(p.EffectiveProcessingTime())
+	if progress > 0 {
+		progressColor := color.RGBA{255, 255, 100, 200}
+		vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+			size*progress, size*0.1, progressColor, false)
+	}
+
+	// Draw operation symbol if zoom is sufficient
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.White)
+		text.Draw(screen, p.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border
+	borderColor := color.RGBA{180, 180, 220, 255}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+// EffectiveProcessingTime returns the processing time after speed modules
+func (p *Processor) EffectiveProcessingTime() int {
+	return p.Modules.ScaleInterval(p.ProcessingTime)
+}
+
+// ScaleWorkTime rescales the processing time
+func (p *Processor) ScaleWorkTime(scale func(ticks int) int) {
+	p.ProcessingTime = scale(p.ProcessingTime)
+}
+
+// AdjustParam changes the processor parameter, keeping it within range
+func (p *Processor) AdjustParam(delta int) {
+	if p.Def.Param == nil {
+		return
+	}
+	// Choices wrap around instead of stopping at the ends
+	if choices := p.Def.op.ParamChoices; len(choices) > 0 {
+		p.Param = ((p.Param+delta)%len(choices) + len(choices)) % len(choices)
+		return
+	}
+	p.Param += delta
+	if p.Param < p.Def.Param.Min {
+		p.Param = p.Def.Param.Min
+	}
+}
+
+// ParamName returns the label of the configurable parameter
+func (p *Processor) ParamName() string {
+	if p.Def.Param == nil {
+		return ""
+	}
+	return p.Def.Param.Name
+}
+
+// ParamText describes the current parameter, e.g. "n = 4" or "function = totient phi"
+func (p *Processor) ParamText() string {
+	if p.Def.Param == nil {
+		return ""
+	}
+	if choices := p.Def.op.ParamChoices; len(choices) > 0 {
+		return fmt.Sprintf("%s = %s", p.Def.Param.Name, choices[p.Param])
+	}
+	return fmt.Sprintf("%s = %d", p.Def.Param.Name, p.Param)
+}
+
+func (p *Processor) CanAcceptInput(fromPos GridPosition) bool {
+	// Always leave room for a full set of inputs
+	if len(p.InputBuffer) >= max(p.MaxBuffer, p.arity()) {
+		return false
+	}
+	for _, side := range p.Def.Ports.Inputs {
+		if p.Position.Neighbor(side.Resolve(p.Facing)) == fromPos {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Processor) AcceptNumber(number *Number) {
+	p.InputBuffer = append(p.InputBuffer, number)
+}
+
+func (p *Processor) GetOutputPosition() GridPosition {
+	return p.Position.Neighbor(p.OutputDir)
+}
+
+// GetOverflowPosition returns the tile surplus goes to, if the processor has an overflow side
+func (p *Processor) GetOverflowPosition() (GridPosition, bool) {
+	return p.Position.Neighbor(p.OverflowDir), p.Def.Ports.Overflow != ""
+}
+
+func (p *Processor) GetRejectPosition() GridPosition {
+	return p.Position.Neighbor(p.RejectDir)
+}
+
+func (p *Processor) TryOutputNumber() *Number {
+	if len(p.OutputBuffer) > 0 {
+		number := p.OutputBuffer[0]
+		p.OutputBuffer = p.OutputBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (p *Processor) TryRejectNumber() *Number {
+	if len(p.RejectBuffer) > 0 {
+		number := p.RejectBuffer[0]
+		p.RejectBuffer = p.RejectBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (p *Processor) HasOutputReady() bool {
+	return len(p.OutputBuffer) > 0
+}
+
+func (p *Processor) HasRejectReady() bool {
+	return len(p.RejectBuffer) > 0
+}
+
+func (p *Processor) GetGridPosition() GridPosition {
+	return p.Position
+}
+
+func (p *Processor) GetSize() (int, int) {
+	return 1, 1
+}
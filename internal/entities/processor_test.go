@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+)
+
+func TestRootKeepsResultsApart(t *testing.T) {
+	registry, err := LoadRegistry("../../data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := NewProcessor(0, 0, registry.Processor("root"), DirectionUp)
+	root.Param = 2
+
+	pair := func(a, b int) Item {
+		return Item{Kind: ItemPair, Components: []nmath.Rational{nmath.Integer(a), nmath.Integer(b)}}
+	}
+	half, _ := nmath.NewRational(1, 2)
+	tests := map[string]struct {
+		input Item
+		want  ItemResult
+	}{
+		"perfect square": {ScalarItem(nmath.Integer(49)), ItemResult{Rejects: []Item{ScalarItem(nmath.Integer(7))}}},
+		"leftover":       {ScalarItem(nmath.Integer(50)), ItemResult{Outputs: []Item{pair(7, 1)}}},
+		"no real root":   {ScalarItem(nmath.Integer(-4)), ItemResult{Outputs: []Item{ScalarItem(nmath.Integer(-4))}}},
+		"fraction":       {ScalarItem(half), ItemResult{Outputs: []Item{ScalarItem(half)}}},
+		"bundle":         {pair(1, 2), ItemResult{Outputs: []Item{pair(1, 2)}}},
+	}
+	for name, tt := range tests {
+		got := root.apply([]Item{tt.input})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: root(%v) = %+v, want %+v", name, tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,755 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+)
+
+// Side is a building face relative to the direction the building faces
+type Side string
+
+const (
+	SideFront Side = "front"
+	SideRight Side = "right"
+	SideBack  Side = "back"
+	SideLeft  Side = "left"
+)
+
+// Resolve returns the absolute direction of the side for a building facing dir
+func (s Side) Resolve(facing Direction) Direction {
+	switch s {
+	case SideRight:
+		return facing.RotateClockwise()
+	case SideBack:
+		return facing.Opposite()
+	case SideLeft:
+		return facing.Opposite().RotateClockwise()
+	default:
+		return facing
+	}
+}
+
+func (s Side) valid() bool {
+	return s == SideFront || s == SideRight || s == SideBack || s == SideLeft
+}
+
+// PortLayout lists which sides of a building take input and emit output.
+// Surplus goes out of the overflow side while the output is blocked.
+// Only accumulators have a trigger side.
+type PortLayout struct {
+	Inputs   []Side `json:"inputs"`
+	Output   Side   `json:"output"`
+	Reject   Side   `json:"reject,omitempty"`
+	Overflow Side   `json:"overflow,omitempty"`
+	Trigger  Side   `json:"trigger,omitempty"`
+}
+
+// ParamDef describes the configurable parameter of a building
+type ParamDef struct {
+	Name    string `json:"name"`
+	Default int    `json:"default"`
+	Min     int    `json:"min"`
+}
+
+// CostDef is an amount of stored items the Core spends on a building or module.
+// Kind is one of ItemKinds.
+type CostDef struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// BuildingKind names a family of buildings defined under the data
+// directory. Miners are the one building without definitions.
+type BuildingKind string
+
+const (
+	KindMiner       BuildingKind = "miner"
+	KindProcessor   BuildingKind = "processor"
+	KindGenerator   BuildingKind = "generator"
+	KindIterator    BuildingKind = "iterator"
+	KindAccumulator BuildingKind = "accumulator"
+	KindVoid        BuildingKind = "void"
+	KindBridge      BuildingKind = "bridge"
+)
+
+// DefBase holds the fields every building definition shares
+type DefBase struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Symbol string    `json:"symbol"`
+	Cost   []CostDef `json:"cost"`
+	Color  [3]uint8  `json:"color"`
+}
+
+// Base returns the shared fields of the definition
+func (d *DefBase) Base() *DefBase {
+	return d
+}
+
+// RGBA returns the base color of the building
+func (d *DefBase) RGBA() color.RGBA {
+	return color.RGBA{d.Color[0], d.Color[1], d.Color[2], 255}
+}
+
+func (d *DefBase) id() string {
+	return d.ID
+}
+
+// validate checks the shared fields, returning one error per problem
+func (d *DefBase) validate() []error {
+	var errs []error
+	if d.ID == "" {
+		errs = append(errs, errors.New("missing \"id\""))
+	}
+	if d.Name == "" {
+		errs = append(errs, errors.New("missing \"name\""))
+	}
+	return append(errs, validateCosts(d.Cost)...)
+}
+
+// BuildingDef is a definition the world can place: it knows its kind and
+// constructs the building facing a direction
+type BuildingDef interface {
+	Base() *DefBase
+	Kind() BuildingKind
+	Build(gridX, gridY int, facing Direction) Entity
+}
+
+// ItemKinds lists the kind names used by costs and filters: "any" and the
+// classes of single numbers, the remaining number tags, then one name per
+// bundle kind
+var ItemKinds = []string{
+	"any", "prime", "composite", "zero", "negative",
+	"fraction", "proper_fraction", "improper_fraction",
+	"even", "odd", "square", "cube", "triangular", "fibonacci",
+	"palindrome", "perfect", "twin_prime", "mersenne",
+	"pair", "tuple", "vector", "set", "polynomial",
+}
+
+// Matches reports whether a stored item can pay for this cost entry
+func (c CostDef) Matches(stored StoredItem) bool {
+	return MatchesKind(c.Kind, stored.Item, stored.Tags)
+}
+
+// MatchesKind reports whether an item belongs to a kind from ItemKinds.
+// "any" covers every single number; bundles only match their own kind.
+// Tag names match whole numbers whose tags, computed when the number was
+// created, include that tag.
+func MatchesKind(kind string, item Item, tags NumberTags) bool {
+	if !item.IsScalar() {
+		return kind == item.Kind.Name()
+	}
+	value := item.Scalar()
+	switch kind {
+	case "any":
+		return true
+	case "negative":
+		return value.Num < 0
+	case "fraction":
+		return !value.IsInteger()
+	case "proper_fraction":
+		return value.IsProper()
+	case "improper_fraction":
+		return !value.IsInteger() && !value.IsProper()
+	}
+	tag, ok := TagByName(kind)
+	return ok && value.IsInteger() && tags.Has(tag)
+}
+
+// ProcessorDef is a processor definition loaded from the data directory
+type ProcessorDef struct {
+	DefBase
+	Operation      string     `json:"operation"`
+	Param          *ParamDef  `json:"param,omitempty"`
+	ProcessingTime int        `json:"processing_time"`
+	Buffer         int        `json:"buffer"`
+	ModuleSlots    int        `json:"module_slots"`
+	Ports          PortLayout `json:"ports"`
+
+	op processorOperation
+}
+
+// GeneratorDef is a generator definition loaded from the data directory.
+// A generator either emits a fixed sequence or draws from a random distribution.
+type GeneratorDef struct {
+	DefBase
+	Sequence     string     `json:"sequence,omitempty"`
+	Distribution string     `json:"distribution,omitempty"`
+	Param        *ParamDef  `json:"param,omitempty"`
+	Interval     int        `json:"interval"`
+	FuelPerTerm  int        `json:"fuel_per_term"`
+	Buffer       int        `json:"buffer"`
+	ModuleSlots  int        `json:"module_slots"`
+	Ports        PortLayout `json:"ports"`
+
+	sequence     nmath.Sequence
+	distribution *nmath.Distribution
+}
+
+// IsRandom reports whether the generator draws from a distribution
+func (d *GeneratorDef) IsRandom() bool {
+	return d.distribution != nil
+}
+
+// IterationRuleFormula is the rule name for iterators driven by a user formula
+const IterationRuleFormula = "formula"
+
+// IteratorDef is an iterator definition loaded from the data directory
+type IteratorDef struct {
+	DefBase
+	Rule          string     `json:"rule"`
+	Formula       string     `json:"formula,omitempty"`
+	MaxIterations int        `json:"max_iterations"`
+	StepTime      int        `json:"step_time"`
+	Buffer        int        `json:"buffer"`
+	ModuleSlots   int        `json:"module_slots"`
+	Ports         PortLayout `json:"ports"`
+
+	formula *nmath.Formula
+}
+
+// AccumulatorDef is an accumulator definition loaded from the data directory
+type AccumulatorDef struct {
+	DefBase
+	Statistic string     `json:"statistic"`
+	Window    int        `json:"window"`
+	Interval  int        `json:"interval"`
+	Buffer    int        `json:"buffer"`
+	Ports     PortLayout `json:"ports"`
+}
+
+// VoidDef is a void definition loaded from the data directory. Voids take
+// numbers from every side; the output side only matters with a filter.
+type VoidDef struct {
+	DefBase
+	Filter string `json:"filter,omitempty"` // starting filter, "" destroys everything
+	Buffer int    `json:"buffer"`
+	Output Side   `json:"output"`
+}
+
+// BridgeDef is a bridge definition loaded from the data directory
+type BridgeDef struct {
+	DefBase
+	Span   int `json:"span"` // most tiles the bridge crosses
+	Buffer int `json:"buffer"`
+}
+
+// Kind and Build make every building definition a BuildingDef
+
+func (d *ProcessorDef) Kind() BuildingKind   { return KindProcessor }
+func (d *GeneratorDef) Kind() BuildingKind   { return KindGenerator }
+func (d *IteratorDef) Kind() BuildingKind    { return KindIterator }
+func (d *AccumulatorDef) Kind() BuildingKind { return KindAccumulator }
+func (d *VoidDef) Kind() BuildingKind        { return KindVoid }
+func (d *BridgeDef) Kind() BuildingKind      { return KindBridge }
+
+func (d *ProcessorDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewProcessor(gridX, gridY, d, facing)
+}
+
+func (d *GeneratorDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewGenerator(gridX, gridY, d, facing)
+}
+
+func (d *IteratorDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewIterator(gridX, gridY, d, facing)
+}
+
+func (d *AccumulatorDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewAccumulator(gridX, gridY, d, facing)
+}
+
+func (d *VoidDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewVoid(gridX, gridY, d, facing)
+}
+
+func (d *BridgeDef) Build(gridX, gridY int, facing Direction) Entity {
+	return NewBridge(gridX, gridY, d, facing)
+}
+
+// Registry holds every building and module definition available to the world
+type Registry struct {
+	Processors   []*ProcessorDef
+	Generators   []*GeneratorDef
+	Iterators    []*IteratorDef
+	Accumulators []*AccumulatorDef
+	Voids        []*VoidDef
+	Bridges      []*BridgeDef
+	Modules      []*ModuleDef
+
+	// Kinds lists the building kinds with at least one definition, in the
+	// order the build menu offers them
+	Kinds     []BuildingKind
+	buildings map[BuildingKind][]BuildingDef
+	byID      map[string]*ProcessorDef
+}
+
+// LoadRegistry reads and validates all definitions under dir.
+// Processors are read from dir/processors/*.json, generators from
+// dir/generators/*.json, iterators from dir/iterators/*.json, accumulators
+// from dir/accumulators/*.json, voids from dir/voids/*.json, bridges from
+// dir/bridges/*.json and modules from dir/modules/*.json, one definition
+// per file.
+func LoadRegistry(dir string) (*Registry, error) {
+	registry := &Registry{
+		buildings: make(map[BuildingKind][]BuildingDef),
+		byID:      make(map[string]*ProcessorDef),
+	}
+
+	var errs, kindErrs []error
+	registry.Processors, kindErrs = loadDefs[ProcessorDef](dir, "processor")
+	errs = append(errs, kindErrs...)
+	if len(registry.Processors) == 0 && len(kindErrs) == 0 {
+		errs = append(errs, fmt.Errorf("no processor definitions found in %s", filepath.Join(dir, "processors")))
+	}
+	registry.Generators, kindErrs = loadDefs[GeneratorDef](dir, "generator")
+	errs = append(errs, kindErrs...)
+	registry.Iterators, kindErrs = loadDefs[IteratorDef](dir, "iterator")
+	errs = append(errs, kindErrs...)
+	registry.Accumulators, kindErrs = loadDefs[AccumulatorDef](dir, "accumulator")
+	errs = append(errs, kindErrs...)
+	registry.Voids, kindErrs = loadDefs[VoidDef](dir, "void")
+	errs = append(errs, kindErrs...)
+	registry.Bridges, kindErrs = loadDefs[BridgeDef](dir, "bridge")
+	errs = append(errs, kindErrs...)
+	registry.Modules, kindErrs = loadDefs[ModuleDef](dir, "module")
+	errs = append(errs, kindErrs...)
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for _, def := range registry.Processors {
+		registry.byID[def.ID] = def
+	}
+	addBuildings(registry, registry.Processors)
+	addBuildings(registry, registry.Generators)
+	addBuildings(registry, registry.Iterators)
+	addBuildings(registry, registry.Accumulators)
+	addBuildings(registry, registry.Voids)
+	addBuildings(registry, registry.Bridges)
+	return registry, nil
+}
+
+// definition is a pointer to a definition type loadDefs can read
+type definition[T any] interface {
+	*T
+	id() string
+	validate() error
+}
+
+// loadDefs reads every dir/<kind>s/*.json file into a definition of type T,
+// in file name order, validating each and rejecting duplicate ids
+func loadDefs[T any, P definition[T]](dir, kind string) ([]P, []error) {
+	files, err := filepath.Glob(filepath.Join(dir, kind+"s", "*.json"))
+	if err != nil {
+		return nil, []error{err}
+	}
+	sort.Strings(files)
+
+	var defs []P
+	var errs []error
+	fileByID := make(map[string]string)
+	for _, file := range files {
+		def := P(new(T))
+		if err := decodeStrict(file, def); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if err := def.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if other, exists := fileByID[def.id()]; exists {
+			errs = append(errs, fmt.Errorf("%s: duplicate %s id %q (already defined in %s)", file, kind, def.id(), other))
+			continue
+		}
+		fileByID[def.id()] = file
+		defs = append(defs, def)
+	}
+	return defs, errs
+}
+
+// addBuildings files building definitions under their kind, listing the
+// kind in Kinds the first time one appears
+func addBuildings[D BuildingDef](r *Registry, defs []D) {
+	for _, def := range defs {
+		kind := def.Kind()
+		if len(r.buildings[kind]) == 0 {
+			r.Kinds = append(r.Kinds, kind)
+		}
+		r.buildings[kind] = append(r.buildings[kind], def)
+	}
+}
+
+// Buildings returns the definitions of a building kind, in file name order
+func (r *Registry) Buildings(kind BuildingKind) []BuildingDef {
+	return r.buildings[kind]
+}
+
+// Processor returns the processor definition with the given id, or nil
+func (r *Registry) Processor(id string) *ProcessorDef {
+	return r.byID[id]
+}
+
+// decodeStrict decodes a JSON file into v, rejecting unknown fields
+func decodeStrict(file string, v any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// validateCosts checks the cost entries of a definition
+func validateCosts(costs []CostDef) []error {
+	var errs []error
+	for _, cost := range costs {
+		if !slices.Contains(ItemKinds, cost.Kind) {
+			errs = append(errs, fmt.Errorf("cost kind %q is not one of %s", cost.Kind, strings.Join(ItemKinds, ", ")))
+		}
+		if cost.Count <= 0 {
+			errs = append(errs, fmt.Errorf("cost count for %q must be positive, got %d", cost.Kind, cost.Count))
+		}
+	}
+	return errs
+}
+
+// validate checks a definition and binds it to its operation
+func (d *ProcessorDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+
+	op, known := processorOperations[d.Operation]
+	if !known {
+		fail("unknown operation %q", d.Operation)
+	}
+	d.op = op
+
+	if d.ProcessingTime <= 0 {
+		fail("\"processing_time\" must be positive, got %d", d.ProcessingTime)
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+
+	if known && op.UsesParam && d.Param == nil {
+		fail("operation %q needs a \"param\"", d.Operation)
+	}
+	if d.Param != nil && d.Param.Default < d.Param.Min {
+		fail("param %q default %d is below its min %d", d.Param.Name, d.Param.Default, d.Param.Min)
+	}
+	if known && len(op.ParamChoices) > 0 && d.Param != nil &&
+		(d.Param.Min != 0 || d.Param.Default >= len(op.ParamChoices)) {
+		fail("operation %q has %d choices, so param min must be 0 and default below %d",
+			d.Operation, len(op.ParamChoices), len(op.ParamChoices))
+	}
+	if known && op.ParamArity && d.Param != nil && d.Param.Min < 0 {
+		fail("operation %q takes param+1 inputs, so param min must not be negative", d.Operation)
+	}
+
+	errs = append(errs, d.Ports.validate()...)
+	if d.Ports.Trigger != "" {
+		fail("only accumulators have a \"ports.trigger\" side")
+	}
+	if d.Ports.Reject == "" && known && op.Rejects {
+		fail("operation %q rejects inputs and needs a \"ports.reject\" side", d.Operation)
+	}
+
+	if d.ModuleSlots < 0 {
+		fail("\"module_slots\" must not be negative, got %d", d.ModuleSlots)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("processor %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks a generator definition and binds it to its sequence
+func (d *GeneratorDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+
+	switch {
+	case d.Sequence != "" && d.Distribution != "":
+		fail("set either \"sequence\" or \"distribution\", not both")
+	case d.Distribution != "":
+		dist, known := nmath.DistributionByID(d.Distribution)
+		if !known {
+			fail("unknown distribution %q", d.Distribution)
+			break
+		}
+		d.distribution = &dist
+		if d.Param == nil {
+			fail("distribution %q needs a \"param\" for its %s", d.Distribution, dist.ParamName)
+		} else if d.Param.Min < dist.MinParam {
+			fail("param %q min %d is below %d, the smallest %s distribution %q supports",
+				d.Param.Name, d.Param.Min, dist.MinParam, dist.ParamName, d.Distribution)
+		}
+	default:
+		seq, known := nmath.SequenceByID(d.Sequence)
+		if !known {
+			fail("unknown sequence %q", d.Sequence)
+		}
+		d.sequence = seq
+		if d.Param != nil {
+			fail("sequence generators take no \"param\"")
+		}
+	}
+	if d.Param != nil && d.Param.Default < d.Param.Min {
+		fail("param %q default %d is below its min %d", d.Param.Name, d.Param.Default, d.Param.Min)
+	}
+
+	if d.Interval <= 0 {
+		fail("\"interval\" must be positive, got %d", d.Interval)
+	}
+	if d.FuelPerTerm < 0 {
+		fail("\"fuel_per_term\" must not be negative, got %d", d.FuelPerTerm)
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+	if d.FuelPerTerm > d.Buffer {
+		fail("\"fuel_per_term\" %d does not fit in a buffer of %d", d.FuelPerTerm, d.Buffer)
+	}
+	if d.ModuleSlots < 0 {
+		fail("\"module_slots\" must not be negative, got %d", d.ModuleSlots)
+	}
+
+	errs = append(errs, d.Ports.validate()...)
+	if d.Ports.Trigger != "" {
+		fail("only accumulators have a \"ports.trigger\" side")
+	}
+	if d.Ports.Reject != "" {
+		fail("generators have no reject port")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("generator %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks an iterator definition and compiles its formula
+func (d *IteratorDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+
+	if d.Rule == IterationRuleFormula {
+		formula, err := nmath.ParseFormula(d.Formula)
+		if err != nil {
+			fail("formula %q: %v", d.Formula, err)
+		}
+		d.formula = formula
+	} else if _, known := iterationRules[d.Rule]; !known {
+		fail("unknown rule %q (want collatz, digit_square_sum or formula)", d.Rule)
+	} else if d.Formula != "" {
+		fail("\"formula\" is only used by the formula rule")
+	}
+
+	if d.MaxIterations <= 0 {
+		fail("\"max_iterations\" must be positive, got %d", d.MaxIterations)
+	}
+	if d.StepTime <= 0 {
+		fail("\"step_time\" must be positive, got %d", d.StepTime)
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+	if d.ModuleSlots < 0 {
+		fail("\"module_slots\" must not be negative, got %d", d.ModuleSlots)
+	}
+
+	errs = append(errs, d.Ports.validate()...)
+	if d.Ports.Trigger != "" {
+		fail("only accumulators have a \"ports.trigger\" side")
+	}
+	if d.Ports.Reject == "" {
+		fail("iterators need a \"ports.reject\" side")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("iterator %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks that every port sits on a distinct, valid side
+func (l PortLayout) validate() []error {
+	var errs []error
+	used := make(map[Side]bool)
+	useSide := func(role string, side Side) {
+		if !side.valid() {
+			errs = append(errs, fmt.Errorf("%s port has invalid side %q (want front, right, back or left)", role, side))
+			return
+		}
+		if used[side] {
+			errs = append(errs, fmt.Errorf("side %q is used by more than one port", side))
+		}
+		used[side] = true
+	}
+
+	if len(l.Inputs) == 0 {
+		errs = append(errs, fmt.Errorf("\"ports.inputs\" needs at least one side"))
+	}
+	for _, side := range l.Inputs {
+		useSide("input", side)
+	}
+	useSide("output", l.Output)
+	if l.Reject != "" {
+		useSide("reject", l.Reject)
+	}
+	if l.Overflow != "" {
+		useSide("overflow", l.Overflow)
+	}
+	if l.Trigger != "" {
+		useSide("trigger", l.Trigger)
+	}
+	return errs
+}
+
+// validate checks an accumulator definition
+func (d *AccumulatorDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+
+	switch d.Statistic {
+	case StatisticSum, StatisticMin, StatisticMax, StatisticCount, StatisticMean:
+	default:
+		fail("unknown statistic %q (want sum, min, max, count or mean)", d.Statistic)
+	}
+
+	if d.Window <= 0 {
+		fail("\"window\" must be positive, got %d", d.Window)
+	}
+	if d.Interval <= 0 {
+		fail("\"interval\" must be positive, got %d", d.Interval)
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+
+	errs = append(errs, d.Ports.validate()...)
+	if d.Ports.Reject == "" {
+		fail("accumulators need a \"ports.reject\" side")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("accumulator %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks a void definition
+func (d *VoidDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+	if d.Filter != "" && !slices.Contains(ItemKinds, d.Filter) {
+		fail("filter %q is not one of %s", d.Filter, strings.Join(ItemKinds, ", "))
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+	if !d.Output.valid() {
+		fail("output port has invalid side %q (want front, right, back or left)", d.Output)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("void %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks a bridge definition
+func (d *BridgeDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, d.DefBase.validate()...)
+	if d.Span <= 0 {
+		fail("\"span\" must be positive, got %d", d.Span)
+	}
+	if d.Buffer <= 0 {
+		fail("\"buffer\" must be positive, got %d", d.Buffer)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("bridge %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks a module definition
+func (d *ModuleDef) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if d.ID == "" {
+		fail("missing \"id\"")
+	}
+	if d.Name == "" {
+		fail("missing \"name\"")
+	}
+	if d.Effect != ModuleSpeed && d.Effect != ModuleProductivity && d.Effect != ModuleConservation {
+		fail("effect %q is not one of speed, productivity, conservation", d.Effect)
+	}
+	if d.Amount <= 0 {
+		fail("\"amount\" must be positive, got %g", d.Amount)
+	}
+	if len(d.Fits) == 0 {
+		fail("\"fits\" needs at least one building kind")
+	}
+	for _, fit := range d.Fits {
+		if fit != FitsMiner && fit != FitsProcessor && fit != FitsGenerator && fit != FitsIterator {
+			fail("fits %q is not one of miner, processor, generator, iterator", fit)
+		}
+	}
+
+	errs = append(errs, validateCosts(d.Cost)...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("module %q: %w", d.ID, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadRegistryData(t *testing.T) {
+	registry, err := LoadRegistry("../../data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BuildingKind{KindProcessor, KindGenerator, KindIterator, KindAccumulator, KindVoid, KindBridge}
+	if !slices.Equal(registry.Kinds, want) {
+		t.Errorf("Kinds = %v, want %v", registry.Kinds, want)
+	}
+	for _, kind := range registry.Kinds {
+		for _, def := range registry.Buildings(kind) {
+			if def.Kind() != kind {
+				t.Errorf("%s %q is filed under %s", def.Kind(), def.Base().ID, kind)
+			}
+			if building := def.Build(0, 0, DirectionUp); building == nil {
+				t.Errorf("%s %q built nothing", kind, def.Base().ID)
+			}
+		}
+	}
+	if registry.Processor("add") == nil {
+		t.Error(`Processor("add") = nil`)
+	}
+}
+
+// writeDefs writes each file into a data directory and returns its path
+func writeDefs(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const testProcessor = `{"id": "neg", "name": "Negate", "operation": "negate", "processing_time": 10, "buffer": 1,
+	"ports": {"inputs": ["back"], "output": "front", "reject": "right"}}`
+
+func TestLoadRegistryErrors(t *testing.T) {
+	tests := map[string]struct {
+		files   map[string]string
+		wantErr string
+	}{
+		"no processors": {
+			files:   map[string]string{"voids/void.json": `{"id": "void", "name": "Void", "buffer": 1, "output": "front"}`},
+			wantErr: "no processor definitions",
+		},
+		"duplicate id": {
+			files: map[string]string{
+				"processors/a.json": testProcessor,
+				"processors/b.json": testProcessor,
+			},
+			wantErr: `duplicate processor id "neg"`,
+		},
+		"unknown field": {
+			files: map[string]string{
+				"processors/a.json": testProcessor,
+				"voids/void.json":   `{"id": "void", "name": "Void", "buffer": 1, "output": "front", "size": 2}`,
+			},
+			wantErr: `unknown field "size"`,
+		},
+		"missing name": {
+			files: map[string]string{
+				"processors/a.json":   testProcessor,
+				"accumulators/a.json": `{"id": "sum", "statistic": "sum", "window": 1, "interval": 1, "buffer": 1, "ports": {"inputs": ["back"], "output": "front", "reject": "left"}}`,
+			},
+			wantErr: `accumulator "sum": missing "name"`,
+		},
+		"bad cost": {
+			files: map[string]string{
+				"processors/a.json": testProcessor,
+				"modules/m.json":    `{"id": "m", "name": "M", "effect": "speed", "amount": 1, "fits": ["miner"], "cost": [{"kind": "gold", "count": 1}]}`,
+			},
+			wantErr: `cost kind "gold"`,
+		},
+	}
+	for name, tt := range tests {
+		_, err := LoadRegistry(writeDefs(t, tt.files))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got error %v, want one mentioning %q", name, err, tt.wantErr)
+		}
+	}
+
+	registry, err := LoadRegistry(writeDefs(t, map[string]string{"processors/a.json": testProcessor}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(registry.Kinds, []BuildingKind{KindProcessor}) {
+		t.Errorf("Kinds = %v, want only processors", registry.Kinds)
+	}
+}
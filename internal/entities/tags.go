@@ -0,0 +1,137 @@
+package entities
+
+import (
+	"image/color"
+
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+)
+
+// NumberTags is the set of properties a whole number has, one bit per tag
+type NumberTags uint32
+
+const (
+	TagZero NumberTags = 1 << iota
+	TagNegative
+	TagEven
+	TagOdd
+	TagPrime
+	TagComposite
+	TagSquare
+	TagCube
+	TagTriangular
+	TagFibonacci
+	TagPalindrome
+	TagPerfect
+	TagTwinPrime
+	TagMersenne
+)
+
+// tagStyle is how a tag shows up on numbers and deposits
+type tagStyle struct {
+	Tag        NumberTags
+	Name       string
+	Icon       string // drawn in the corner of deposits, "" for none
+	Color      color.RGBA
+	Background color.RGBA // deposit fill
+}
+
+// tagStyles lists every tag from highest display priority to lowest. A
+// number is drawn in the style of the first tag it has, so rarer tags win.
+var tagStyles = []tagStyle{
+	{TagZero, "zero", "", color.RGBA{220, 220, 220, 255}, color.RGBA{110, 110, 110, 255}},
+	{TagNegative, "negative", "", color.RGBA{200, 100, 220, 255}, color.RGBA{110, 50, 130, 255}},
+	{TagPerfect, "perfect", "★", color.RGBA{255, 215, 0, 255}, color.RGBA{150, 120, 20, 255}},
+	{TagMersenne, "mersenne", "M", color.RGBA{0, 255, 200, 255}, color.RGBA{20, 130, 110, 255}},
+	{TagCube, "cube", "³", color.RGBA{255, 120, 200, 255}, color.RGBA{140, 50, 110, 255}},
+	{TagSquare, "square", "²", color.RGBA{100, 200, 255, 255}, color.RGBA{40, 100, 150, 255}},
+	{TagFibonacci, "fibonacci", "F", color.RGBA{255, 180, 60, 255}, color.RGBA{150, 95, 20, 255}},
+	{TagTriangular, "triangular", "△", color.RGBA{180, 230, 90, 255}, color.RGBA{95, 130, 40, 255}},
+	{TagTwinPrime, "twin_prime", "T", color.RGBA{160, 255, 160, 255}, color.RGBA{60, 170, 80, 255}},
+	{TagPrime, "prime", "", color.RGBA{100, 255, 100, 255}, color.RGBA{50, 150, 50, 255}},
+	{TagPalindrome, "palindrome", "P", color.RGBA{240, 140, 140, 255}, color.RGBA{140, 70, 70, 255}},
+	{TagComposite, "composite", "", color.RGBA{255, 150, 100, 255}, color.RGBA{150, 80, 50, 255}},
+	{TagEven, "even", "", color.RGBA{150, 150, 255, 255}, color.RGBA{50, 50, 150, 255}},
+	{TagOdd, "odd", "", color.RGBA{150, 150, 255, 255}, color.RGBA{50, 50, 150, 255}},
+}
+
+// defaultTagStyle is used for numbers without any tag
+var defaultTagStyle = tagStyle{Color: color.RGBA{150, 150, 255, 255}, Background: color.RGBA{50, 50, 150, 255}}
+
+// ClassifyNumber computes every tag of a whole number. Cube and palindrome
+// hold for negative numbers too; the other tags describe positive numbers,
+// so zero is only tagged zero and even.
+func ClassifyNumber(value int) NumberTags {
+	var tags NumberTags
+	set := func(tag NumberTags, has bool) {
+		if has {
+			tags |= tag
+		}
+	}
+
+	set(TagZero, value == 0)
+	set(TagNegative, value < 0)
+	set(TagEven, value%2 == 0)
+	set(TagOdd, value%2 != 0)
+	set(TagCube, value != 0 && nmath.IsCube(value))
+	set(TagPalindrome, nmath.IsPalindrome(value))
+	if value < 1 {
+		return tags
+	}
+
+	prime := nmath.IsPrime(value)
+	set(TagPrime, prime)
+	set(TagComposite, value > 1 && !prime)
+	set(TagSquare, nmath.IsSquare(value))
+	set(TagTriangular, nmath.IsTriangular(value))
+	set(TagFibonacci, nmath.IsFibonacci(value))
+	set(TagPerfect, nmath.IsPerfect(value))
+	set(TagTwinPrime, prime && nmath.IsTwinPrime(value))
+	set(TagMersenne, prime && nmath.IsMersennePrime(value))
+	return tags
+}
+
+// Has reports whether every tag in tag is set
+func (t NumberTags) Has(tag NumberTags) bool {
+	return t&tag == tag
+}
+
+// Names lists the tags in display priority order
+func (t NumberTags) Names() []string {
+	var names []string
+	for _, style := range tagStyles {
+		if t.Has(style.Tag) {
+			names = append(names, style.Name)
+		}
+	}
+	return names
+}
+
+// primaryStyle returns the style of the highest priority tag
+func (t NumberTags) primaryStyle() tagStyle {
+	for _, style := range tagStyles {
+		if t.Has(style.Tag) {
+			return style
+		}
+	}
+	return defaultTagStyle
+}
+
+// Icon returns the icon of the highest priority tag that has one
+func (t NumberTags) Icon() string {
+	for _, style := range tagStyles {
+		if style.Icon != "" && t.Has(style.Tag) {
+			return style.Icon
+		}
+	}
+	return ""
+}
+
+// TagByName looks up a tag by the name used in costs and filters
+func TagByName(name string) (NumberTags, bool) {
+	for _, style := range tagStyles {
+		if style.Name == name {
+			return style.Tag, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClassifyNumber(t *testing.T) {
+	tests := []struct {
+		value int
+		want  []string
+	}{
+		{0, []string{"zero", "even"}},
+		{1, []string{"cube", "square", "fibonacci", "triangular", "odd"}},
+		{8, []string{"cube", "fibonacci", "composite", "even"}},
+		{-8, []string{"negative", "cube", "even"}},
+		{121, []string{"square", "palindrome", "composite", "odd"}},
+		{-121, []string{"negative", "palindrome", "odd"}},
+		{-7, []string{"negative", "odd"}},
+		{7, []string{"mersenne", "twin_prime", "prime", "odd"}},
+	}
+	for _, tt := range tests {
+		if got := ClassifyNumber(tt.value).Names(); !slices.Equal(got, tt.want) {
+			t.Errorf("ClassifyNumber(%d) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
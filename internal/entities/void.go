@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"image/color"
+
+	"github.com/Sanjar0126/math-factory/internal/fonts"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Void destroys the numbers it receives. Without a filter it takes numbers
+// from every side and destroys all of them; with a filter it only destroys
+// numbers of that kind and passes the rest out of its output side.
+type Void struct {
+	Position   GridPosition
+	Def        *VoidDef
+	OutputDir  Direction
+	Filter     string // one of ItemKinds, or "" to destroy everything
+	PassBuffer []*Number
+	MaxBuffer  int
+	Destroyed  int
+}
+
+func NewVoid(gridX, gridY int, def *VoidDef, facing Direction) *Void {
+	return &Void{
+		Position:   GridPosition{X: gridX, Y: gridY},
+		Def:        def,
+		OutputDir:  def.Output.Resolve(facing),
+		Filter:     def.Filter,
+		PassBuffer: make([]*Number, 0),
+		MaxBuffer:  def.Buffer,
+	}
+}
+
+func (v *Void) Update() {
+	// Voids act as soon as numbers arrive
+}
+
+// CycleFilter steps through "everything" and each of ItemKinds
+func (v *Void) CycleFilter(step int) {
+	filters := append([]string{""}, ItemKinds...)
+	index := 0
+	for i, filter := range filters {
+		if filter == v.Filter {
+			index = i
+		}
+	}
+	n := len(filters)
+	v.Filter = filters[((index+step)%n+n)%n]
+}
+
+// FilterName describes what the void destroys
+func (v *Void) FilterName() string {
+	if v.Filter == "" {
+		return "everything"
+	}
+	return v.Filter
+}
+
+func (v *Void) Draw(screen *ebiten.Image, camera CameraInterface) {
+	worldX, worldY := v.Position.ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	zoom := camera.GetZoom()
+	size := float32(TileSize) * float32(zoom)
+
+	if size < 4 {
+		return
+	}
+
+	// Draw void base
+	vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+		size, size, v.Def.RGBA(), false)
+
+	// Filtered voids pass the rest on
+	if v.Filter != "" {
+		drawDirectionIndicator(screen, float32(screenX), float32(screenY), size, v.OutputDir, color.RGBA{255, 200, 100, 255})
+	}
+
+	if zoom > 0.6 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(screenX+4, screenY+20)
+		opts.ColorScale.ScaleWithColor(color.RGBA{200, 80, 80, 255})
+		text.Draw(screen, v.Def.Symbol, fonts.MplusNormalFont, opts)
+	}
+
+	// Draw border
+	borderColor := color.RGBA{120, 60, 60, 255}
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		size, size, 2, borderColor, false)
+}
+
+func (v *Void) CanAcceptInput(fromPos GridPosition) bool {
+	if v.Filter != "" && fromPos == v.GetOutputPosition() {
+		return false
+	}
+	return len(v.PassBuffer) < v.MaxBuffer
+}
+
+func (v *Void) AcceptNumber(number *Number) {
+	if v.Filter == "" || MatchesKind(v.Filter, number.Item(), number.Tags) {
+		v.Destroyed++
+		return
+	}
+	v.PassBuffer = append(v.PassBuffer, number)
+}
+
+func (v *Void) GetOutputPosition() GridPosition {
+	return v.Position.Neighbor(v.OutputDir)
+}
+
+func (v *Void) TryOutputNumber() *Number {
+	if len(v.PassBuffer) > 0 {
+		number := v.PassBuffer[0]
+		v.PassBuffer = v.PassBuffer[1:]
+		return number
+	}
+	return nil
+}
+
+func (v *Void) HasOutputReady() bool {
+	return len(v.PassBuffer) > 0
+}
+
+func (v *Void) GetGridPosition() GridPosition {
+	return v.Position
+}
+
+func (v *Void) GetSize() (int, int) {
+	return 1, 1
+}
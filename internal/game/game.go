@@ -3,10 +3,16 @@ package game
 import (
 	"fmt"
 	"image/color"
+	"math/rand"
+	"path/filepath"
+	"strings"
 
+	"github.com/Sanjar0126/math-factory/internal/entities"
 	"github.com/Sanjar0126/math-factory/internal/fonts"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/Sanjar0126/math-factory/internal/systems"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 // Game represents the main game state
@@ -16,11 +22,35 @@ type Game struct {
 	world        *World
 	camera       *Camera
 	input        *InputManager
+
+	// newWorld is the fresh world waiting for the player to confirm that
+	// the current factory may be thrown away, or nil
+	newWorld *newWorldRequest
 }
 
-// NewGame creates a new game instance
-func NewGame(screenWidth, screenHeight int) *Game {
-	world := NewWorld()
+// newWorldRequest is a pending switch to a new world in Mode, confirmed
+// by pressing Key again
+type newWorldRequest struct {
+	Key  ebiten.Key
+	Mode systems.WorldMode
+}
+
+// NewGame creates a new game instance, loading building definitions and
+// the world layout from dataDir. A seed of 0 picks a fresh random world.
+func NewGame(screenWidth, screenHeight int, dataDir string, seed int64, mode systems.WorldMode) (*Game, error) {
+	registry, err := entities.LoadRegistry(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading building definitions: %w", err)
+	}
+	config, err := systems.LoadGenerationConfig(filepath.Join(dataDir, "generation.json"))
+	if err != nil {
+		return nil, fmt.Errorf("loading world generation config: %w", err)
+	}
+
+	if seed == 0 {
+		seed = NewSeed()
+	}
+	world := NewWorld(registry, seed, mode, config)
 	camera := NewCamera(screenWidth, screenHeight)
 	input := NewInputManager()
 	fonts.InitFonts()
@@ -31,6 +61,15 @@ func NewGame(screenWidth, screenHeight int) *Game {
 		world:        world,
 		camera:       camera,
 		input:        input,
+	}, nil
+}
+
+// NewSeed picks a random nonzero world seed
+func NewSeed() int64 {
+	for {
+		if seed := rand.Int63(); seed != 0 {
+			return seed
+		}
 	}
 }
 
@@ -39,8 +78,24 @@ func (g *Game) Update() error {
 	// Update input
 	g.input.Update()
 
-	// Handle camera movement
-	g.camera.HandleInput(g.input)
+	// Handle camera movement, unless the keyboard is busy with text entry
+	if g.world.FormulaEdit == nil {
+		g.camera.HandleInput(g.input)
+	}
+
+	// Start over in a fresh random world, in the same or the next mode,
+	// once the player confirms by pressing the same key again
+	if g.world.FormulaEdit == nil {
+		mode := g.world.Generation.Mode
+		switch {
+		case g.input.IsKeyJustPressed(ebiten.KeyF2):
+			g.requestNewWorld(ebiten.KeyF2, mode)
+		case g.input.IsKeyJustPressed(ebiten.KeyF3):
+			g.requestNewWorld(ebiten.KeyF3, mode.Next())
+		case g.input.IsKeyJustPressed(ebiten.KeyEscape):
+			g.newWorld = nil
+		}
+	}
 
 	// Handle world input (building placement, etc.)
 	g.world.HandleInput(g.input, g.camera)
@@ -51,6 +106,17 @@ func (g *Game) Update() error {
 	return nil
 }
 
+// requestNewWorld asks for confirmation the first time key is pressed and
+// replaces the world with a fresh one in mode when it is pressed again
+func (g *Game) requestNewWorld(key ebiten.Key, mode systems.WorldMode) {
+	if g.newWorld == nil || g.newWorld.Key != key {
+		g.newWorld = &newWorldRequest{Key: key, Mode: mode}
+		return
+	}
+	g.world = NewWorld(g.world.Registry, NewSeed(), g.newWorld.Mode, g.world.Generation.Config)
+	g.newWorld = nil
+}
+
 // Draw renders the game
 func (g *Game) Draw(screen *ebiten.Image) {
 	// Clear screen
@@ -61,6 +127,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	// Draw UI
 	g.drawUI(screen)
+	g.drawTooltip(screen)
 }
 
 // Layout returns the screen dimensions
@@ -73,25 +140,188 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 	numbersInWorld, numbersStored, minerCount, depositCount := g.world.GetStats()
 
 	uiText := fmt.Sprintf("Math Factory v0.3 - Grid System\n"+
-		"WASD: Move camera, Mouse wheel: Zoom\n"+
-		"B: Toggle build mode, 1: Miner, 2: Conveyor\n"+
-		"Camera: (%.1f, %.1f) Zoom: %.2f\n"+
+		"WASD: Move camera, Mouse wheel: Zoom, X: Labels (%s), F2: New world, F3: Switch mode\n"+
+		"B: Toggle build mode, %s\n"+
+		"Q/E: Cycle operation, R: Rotate, Click: Inspect, +/-: Adjust, [/]: Rate\n"+
+		"Inspecting: 1-9: Insert module, Backspace: Remove module\n"+
+		"Seed: %d (%s), Camera: (%.1f, %.1f) Zoom: %.2f\n"+
 		"Numbers in world: %d, Stored: %d\n"+
 		"Miners: %d, Deposits: %d",
-		g.camera.X, g.camera.Y, g.camera.Zoom,
+		entities.LabelBase.Name(), buildKeysText(g.world.BuildKinds()),
+		g.world.Generation.Seed, g.world.Generation.Mode.Name(), g.camera.X, g.camera.Y, g.camera.Zoom,
 		numbersInWorld, numbersStored,
 		minerCount, depositCount)
 
-	if g.world.BuildMode {
-		buildingName := "Unknown"
-		switch g.world.SelectedBuilding {
-		case BuildingMiner:
-			buildingName = "Miner"
-		case BuildingConveyor:
-			buildingName = "Conveyor"
+	if request := g.newWorld; request != nil {
+		uiText += fmt.Sprintf("\nNEW WORLD: press %s again to abandon this factory for a new %s world, Esc: cancel",
+			request.Key, request.Mode.Name())
+	} else if g.world.BuildMode {
+		buildingName := kindName(g.world.SelectedKind)
+		if def := g.world.SelectedDef(); def != nil {
+			buildingName += fmt.Sprintf(" (%s) Cost: %s", def.Base().Name, costText(def.Base().Cost))
+		}
+		uiText += fmt.Sprintf("\nBUILD MODE: %s, Facing: %s", buildingName, directionName(g.world.PlacementDir))
+	} else if edit := g.world.FormulaEdit; edit != nil {
+		uiText += fmt.Sprintf("\nFORMULA: %s_  (Enter: apply, Esc: cancel)", edit.Text)
+		if edit.Error != "" {
+			uiText += "\nError: " + edit.Error
+		}
+	} else if g.world.SelectedEntity != nil {
+		uiText += "\n" + inspectorText(g.world.SelectedEntity)
+		if slots, _ := moduleSlotsOf(g.world.SelectedEntity); slots != nil {
+			uiText += "\n" + moduleKeysText(g.world.Registry.Modules)
 		}
-		uiText += fmt.Sprintf("\nBUILD MODE: %s", buildingName)
 	}
 
 	ebitenutil.DebugPrintAt(screen, uiText, 10, 10)
 }
+
+// drawTooltip shows the full value of the number or deposit under the mouse
+func (g *Game) drawTooltip(screen *ebiten.Image) {
+	if g.world.FormulaEdit != nil {
+		return
+	}
+	mouseX, mouseY := g.input.GetMousePosition()
+	worldX, worldY := g.camera.ScreenToWorld(mouseX, mouseY)
+	tooltip := g.world.HoverText(worldX, worldY)
+	if tooltip == "" {
+		return
+	}
+
+	// The debug font is 6x16 pixels per character
+	x, y := mouseX+16, mouseY+16
+	width := float32(len(tooltip)*6 + 8)
+	if x+int(width) > g.screenWidth {
+		x = max(g.screenWidth-int(width), 0)
+	}
+	vector.DrawFilledRect(screen, float32(x-4), float32(y-2), width, 20, color.RGBA{0, 0, 0, 200}, false)
+	ebitenutil.DebugPrintAt(screen, tooltip, x, y)
+}
+
+// inspectorText describes the state of a selected building
+func inspectorText(entity entities.Entity) string {
+	switch e := entity.(type) {
+	case *entities.Processor:
+		name := e.Def.Name
+		if e.ParamName() != "" {
+			name += ", " + e.ParamText()
+		}
+		return fmt.Sprintf("Processor: %s\n"+
+			"Input: %d, Output: %d, Rejected: %d, Time: %d ticks\n"+
+			"Modules: %s\n%s",
+			name, len(e.InputBuffer), len(e.OutputBuffer), len(e.RejectBuffer), e.EffectiveProcessingTime(),
+			e.Modules.Summary(), e.Modules.Stats())
+	case *entities.Generator:
+		status := fmt.Sprintf("start index %d, next index %d", e.StartIndex, e.NextIndex)
+		if e.Def.IsRandom() {
+			status = fmt.Sprintf("%s = %d, seed %d, drawn %d (\\: reset)", e.Def.Param.Name, e.Param, e.Seed, e.NextIndex)
+		}
+		if e.Exhausted {
+			status = "exhausted"
+		}
+		return fmt.Sprintf("Generator: %s, %s\n"+
+			"Fuel: %d (%d per term), Output: %d, Interval: %d ticks\n"+
+			"Modules: %s\n%s",
+			e.SequenceName(), status,
+			len(e.FuelBuffer), e.Def.FuelPerTerm, len(e.OutputBuffer), e.EffectiveInterval(),
+			e.Modules.Summary(), e.Modules.Stats())
+	case *entities.Iterator:
+		rule := e.Def.Rule
+		if e.UsesFormula() {
+			rule = fmt.Sprintf("x -> %s (F: edit)", e.Formula.Source)
+		}
+		status := "idle"
+		if e.Held {
+			status = fmt.Sprintf("holding %d, step %d/%d", e.Current, e.Steps, e.MaxIterations)
+		}
+		return fmt.Sprintf("Iterator: %s, emits %s (M: toggle)\n"+
+			"%s, Input: %d, Output: %d, Rejected: %d\n"+
+			"Modules: %s\n%s",
+			rule, e.Mode.Name(),
+			status, len(e.InputBuffer), len(e.OutputBuffer), len(e.RejectBuffer),
+			e.Modules.Summary(), e.Modules.Stats())
+	case *entities.Accumulator:
+		current := "none"
+		if value, ok := e.ExactValue(); ok {
+			current = entities.FormatSummary(value)
+			if !value.IsInt64() {
+				current += " (too large to emit)"
+			}
+		}
+		return fmt.Sprintf("Accumulator: %s, window %d/%d, emits every %d ticks (T: trigger)\n"+
+			"Current: %s, Output: %d, Rejected: %d",
+			e.Def.Name, e.WindowFill(), e.Window, e.Interval,
+			current, len(e.OutputBuffer), len(e.RejectBuffer))
+	case *entities.Void:
+		return fmt.Sprintf("Void: %s, destroys %s (+/-: filter), destroyed %d, passing %d",
+			e.Def.Name, e.FilterName(), e.Destroyed, len(e.PassBuffer))
+	case *entities.Bridge:
+		return fmt.Sprintf("Bridge: %s, crosses %d of up to %d tiles, carrying %d",
+			e.Def.Name, e.Crossed, e.Def.Span, len(e.Buffer))
+	case *entities.Miner:
+		return fmt.Sprintf("Miner: deposit %d (%s), buffer %d/%d, interval: %d ticks\n"+
+			"Modules: %s\n%s",
+			e.Deposit.NumberValue, strings.Join(e.Deposit.Tags.Names(), ", "),
+			len(e.OutputBuffer), e.MaxBuffer, e.EffectiveInterval(),
+			e.Modules.Summary(), e.Modules.Stats())
+	case *entities.Core:
+		return fmt.Sprintf("Core: %d stored, %d fractions, %d bundles",
+			e.GetStoredCount(), e.GetStoredFractionCount(), e.GetStoredBundleCount())
+	default:
+		return ""
+	}
+}
+
+// moduleKeysText lists the key and cost of each module, e.g. "1: Speed (10 any)"
+func moduleKeysText(modules []*entities.ModuleDef) string {
+	parts := make([]string, 0, len(modules))
+	for i, def := range modules {
+		if i >= len(moduleKeys) {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d: %s (%s)", i+1, def.Name, costText(def.Cost)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildKeysText lists the key of each building kind, e.g. "1: Miner, 2: Processor"
+func buildKeysText(kinds []entities.BuildingKind) string {
+	parts := make([]string, 0, len(kinds))
+	for i, kind := range kinds {
+		if i >= len(buildKeys) {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d: %s", i+1, kindName(kind)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// kindName returns the display name of a building kind, e.g. "Processor"
+func kindName(kind entities.BuildingKind) string {
+	return strings.ToUpper(string(kind[:1])) + string(kind[1:])
+}
+
+// costText lists building costs, e.g. "3 any, 2 prime"
+func costText(costs []entities.CostDef) string {
+	if len(costs) == 0 {
+		return "free"
+	}
+	parts := make([]string, len(costs))
+	for i, cost := range costs {
+		parts[i] = fmt.Sprintf("%d %s", cost.Count, cost.Kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func directionName(dir entities.Direction) string {
+	switch dir {
+	case entities.DirectionUp:
+		return "Up"
+	case entities.DirectionRight:
+		return "Right"
+	case entities.DirectionDown:
+		return "Down"
+	default:
+		return "Left"
+	}
+}
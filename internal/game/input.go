@@ -39,6 +39,11 @@ func (im *InputManager) GetMousePosition() (int, int) {
 	return im.mouseX, im.mouseY
 }
 
+// AppendInputChars appends the characters typed this tick to runes
+func (im *InputManager) AppendInputChars(runes []rune) []rune {
+	return ebiten.AppendInputChars(runes)
+}
+
 func (im *InputManager) GetWheelDelta() (float64, float64) {
 	return im.wheelX, im.wheelY
 }
@@ -1,44 +1,61 @@
 package game
 
 import (
+	"fmt"
 	"image/color"
 	"math"
-	"math/rand"
+	"strings"
 
 	"github.com/Sanjar0126/math-factory/internal/entities"
+	"github.com/Sanjar0126/math-factory/internal/systems"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
 	TileSize = 32
-)
-
-// BuildingType represents different types of buildings
-type BuildingType int
 
-const (
-	BuildingMiner BuildingType = iota
-	BuildingConveyor
-	BuildingProcessor
+	// FloatingLifetime is how many ticks a floating number outside the Core's
+	// pull survives before it disappears
+	FloatingLifetime = 60 * 60
 )
 
+// FormulaEditor holds the text being typed as a new iterator formula
+type FormulaEditor struct {
+	Target *entities.Iterator
+	Text   string
+	Error  string
+}
+
 // World represents the game world with grid-based entities
 type World struct {
 	// Grid-based storage
-	Grid     map[entities.GridPosition]entities.Entity
-	Deposits map[entities.GridPosition]*entities.NumberDeposit
-	Core     *entities.Core
-	Miners   []*entities.Miner
-	Numbers  []*entities.Number
+	Grid      map[entities.GridPosition]entities.Entity
+	Deposits  map[entities.GridPosition]*entities.NumberDeposit
+	Core      *entities.Core
+	Miners    []*entities.Miner
+	Buildings []entities.Entity // buildings placed from definitions, in placement order
+	Numbers   []*entities.Number
+
+	// Data-driven building definitions
+	Registry *entities.Registry
 
 	// Building placement
-	SelectedBuilding BuildingType
-	BuildMode        bool
-	PreviewPosition  entities.GridPosition
+	SelectedKind    entities.BuildingKind
+	SelectedDefs    map[entities.BuildingKind]int // index into Registry.Buildings of each kind
+	PlacementDir    entities.Direction
+	BuildMode       bool
+	PreviewPosition entities.GridPosition
+
+	// Inspection of placed buildings
+	SelectedEntity entities.Entity
+	FormulaEdit    *FormulaEditor
 
 	// World generation
+	Generation      *systems.WorldGen
 	GeneratedChunks map[ChunkPosition]bool
+	Zones           map[entities.GridPosition]systems.ZoneType    // cache for drawing
+	Terrain         map[entities.GridPosition]systems.TerrainType // generated tiles that are not open
 }
 
 // ChunkPosition represents a chunk of the world (for generation)
@@ -48,16 +65,24 @@ type ChunkPosition struct {
 
 const ChunkSize = 16 // 16x16 tiles per chunk
 
-// NewWorld creates a new grid-based world
-func NewWorld() *World {
+// NewWorld creates a new grid-based world using the given building
+// definitions, generating its map from seed in the given mode and layout
+func NewWorld(registry *entities.Registry, seed int64, mode systems.WorldMode, config systems.GenerationConfig) *World {
 	world := &World{
-		Grid:             make(map[entities.GridPosition]entities.Entity),
-		Deposits:         make(map[entities.GridPosition]*entities.NumberDeposit),
-		Miners:           make([]*entities.Miner, 0),
-		Numbers:          make([]*entities.Number, 0),
-		SelectedBuilding: BuildingMiner,
-		BuildMode:        false,
-		GeneratedChunks:  make(map[ChunkPosition]bool),
+		Grid:            make(map[entities.GridPosition]entities.Entity),
+		Deposits:        make(map[entities.GridPosition]*entities.NumberDeposit),
+		Miners:          make([]*entities.Miner, 0),
+		Buildings:       make([]entities.Entity, 0),
+		Numbers:         make([]*entities.Number, 0),
+		Registry:        registry,
+		SelectedKind:    entities.KindMiner,
+		SelectedDefs:    make(map[entities.BuildingKind]int),
+		PlacementDir:    entities.DirectionRight,
+		BuildMode:       false,
+		GeneratedChunks: make(map[ChunkPosition]bool),
+		Generation:      systems.NewWorldGen(seed, mode, config),
+		Zones:           make(map[entities.GridPosition]systems.ZoneType),
+		Terrain:         make(map[entities.GridPosition]systems.TerrainType),
 	}
 
 	// Create core at origin (0,0) - it's 2x2 so occupies (0,0), (1,0), (0,1), (1,1)
@@ -80,19 +105,16 @@ func (w *World) Update() {
 	// Update core
 	w.Core.Update()
 
-	// Check miners for output and handle number movement
+	// Move produced numbers out of miners, generators and the other buildings
 	for _, miner := range w.Miners {
-		if miner.HasOutputReady() {
-			// For now, output directly as floating numbers
-			// Later this will be handled by conveyor system
-			if number := miner.TryOutputNumber(); number != nil {
-				// Position number at miner's output position
-				outputPos := miner.GetOutputPosition()
-				outputWorldX, outputWorldY := outputPos.ToWorldPos()
-				number.X = outputWorldX + TileSize/2
-				number.Y = outputWorldY + TileSize/2
-				w.Numbers = append(w.Numbers, number)
-			}
+		w.flushProducer(miner)
+	}
+	for _, building := range w.Buildings {
+		if producer, ok := building.(entities.NumberProducer); ok {
+			w.flushProducer(producer)
+		}
+		if rejecter, ok := building.(entities.RejectProducer); ok {
+			w.flushRejects(building.GetGridPosition(), rejecter)
 		}
 	}
 
@@ -119,24 +141,227 @@ func (w *World) Update() {
 				w.Core.AcceptNumber(number)
 				w.Numbers = append(w.Numbers[:i], w.Numbers[i+1:]...)
 			}
+			continue
+		}
+
+		// Numbers the Core never pulls in eventually disappear
+		number.Age++
+		if number.Age > FloatingLifetime {
+			w.Numbers = append(w.Numbers[:i], w.Numbers[i+1:]...)
 		}
 	}
 }
 
+// flushProducer moves the next ready number of a producer to its output tile,
+// or to its overflow tile while the output is blocked
+func (w *World) flushProducer(producer entities.NumberProducer) {
+	if !producer.HasOutputReady() {
+		return
+	}
+
+	from := producer.GetGridPosition()
+	if spanner, ok := producer.(entities.Spanner); ok {
+		from = spanner.ArrivalFrom()
+	}
+	outputPos := producer.GetOutputPosition()
+	if w.canDeliver(from, outputPos) {
+		w.deliver(producer.TryOutputNumber(), from, outputPos)
+		return
+	}
+
+	if overflow, ok := producer.(entities.OverflowProducer); ok {
+		if overflowPos, has := overflow.GetOverflowPosition(); has && w.canDeliver(from, overflowPos) {
+			w.deliver(producer.TryOutputNumber(), from, overflowPos)
+		}
+	}
+}
+
+// flushRejects moves the next rejected number of a building to its reject tile
+func (w *World) flushRejects(from entities.GridPosition, producer entities.RejectProducer) {
+	rejectPos := producer.GetRejectPosition()
+	if producer.HasRejectReady() && w.canDeliver(from, rejectPos) {
+		w.deliver(producer.TryRejectNumber(), from, rejectPos)
+	}
+}
+
+// canDeliver reports whether a number can leave from one tile onto another.
+// Empty tiles take numbers (they float) unless their terrain blocks them,
+// buildings only if they accept input.
+func (w *World) canDeliver(from, to entities.GridPosition) bool {
+	entity, occupied := w.Grid[to]
+	if !occupied {
+		return !w.terrainAt(to).BlocksNumbers()
+	}
+	acceptor, ok := entity.(entities.NumberAcceptor)
+	return ok && acceptor.CanAcceptInput(from)
+}
+
+// deliver hands a number coming from one tile to the building at pos, or drops
+// it there as a floating number. Numbers reaching a trigger port or dropped
+// into a rift are used up.
+func (w *World) deliver(number *entities.Number, from, pos entities.GridPosition) {
+	if trigger, ok := w.Grid[pos].(entities.TriggerAcceptor); ok && trigger.IsTriggerFrom(from) {
+		trigger.Trigger()
+		return
+	}
+	if acceptor, ok := w.Grid[pos].(entities.NumberAcceptor); ok {
+		acceptor.AcceptNumber(number)
+		return
+	}
+	if w.terrainAt(pos).SwallowsNumbers() {
+		return
+	}
+
+	worldX, worldY := pos.ToWorldPos()
+	number.X = worldX + TileSize/2
+	number.Y = worldY + TileSize/2
+	w.Numbers = append(w.Numbers, number)
+}
+
 // HandleInput processes world-related input
 func (w *World) HandleInput(input *InputManager, camera *Camera) {
+	// Formula entry takes over the keyboard until applied or cancelled
+	if w.FormulaEdit != nil {
+		w.handleFormulaInput(input)
+		w.generateAroundCamera(camera)
+		return
+	}
+
 	// Toggle build mode
 	if input.IsKeyJustPressed(ebiten.KeyB) {
 		w.BuildMode = !w.BuildMode
 	}
 
+	// Cycle number labels between decimal, binary and hex
+	if input.IsKeyJustPressed(ebiten.KeyX) {
+		entities.LabelBase = entities.LabelBase.Next()
+	}
+
 	// Cycle building types in build mode
 	if w.BuildMode {
-		if input.IsKeyJustPressed(ebiten.Key1) {
-			w.SelectedBuilding = BuildingMiner
+		for i, kind := range w.BuildKinds() {
+			if i < len(buildKeys) && input.IsKeyJustPressed(buildKeys[i]) {
+				w.SelectedKind = kind
+			}
+		}
+
+		// Cycle the definitions of the selected kind, such as processor operations
+		step := 0
+		if input.IsKeyJustPressed(ebiten.KeyE) {
+			step = 1
 		}
-		if input.IsKeyJustPressed(ebiten.Key2) {
-			w.SelectedBuilding = BuildingConveyor
+		if input.IsKeyJustPressed(ebiten.KeyQ) {
+			step = -1
+		}
+		if defs := w.Registry.Buildings(w.SelectedKind); step != 0 && len(defs) > 0 {
+			w.SelectedDefs[w.SelectedKind] = cycleIndex(w.SelectedDefs[w.SelectedKind], step, len(defs))
+		}
+
+		// Rotate output direction
+		if input.IsKeyJustPressed(ebiten.KeyR) {
+			w.PlacementDir = w.PlacementDir.RotateClockwise()
+		}
+	}
+
+	// Select placed buildings outside of build mode
+	if !w.BuildMode && input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		mouseX, mouseY := input.GetMousePosition()
+		worldX, worldY := camera.ScreenToWorld(mouseX, mouseY)
+		w.SelectedEntity = w.Grid[entities.WorldPosToGrid(worldX, worldY)]
+	}
+
+	// Configure the selected processor
+	if processor, ok := w.SelectedEntity.(*entities.Processor); ok {
+		if input.IsKeyJustPressed(ebiten.KeyEqual) {
+			processor.AdjustParam(1)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyMinus) {
+			processor.AdjustParam(-1)
+		}
+	}
+
+	// Configure the selected generator
+	if generator, ok := w.SelectedEntity.(*entities.Generator); ok {
+		delta := 0
+		if input.IsKeyJustPressed(ebiten.KeyEqual) {
+			delta = 1
+		}
+		if input.IsKeyJustPressed(ebiten.KeyMinus) {
+			delta = -1
+		}
+		if delta != 0 && generator.Def.IsRandom() {
+			generator.AdjustParam(delta)
+		} else if delta != 0 {
+			generator.AdjustStartIndex(delta)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBackslash) {
+			generator.ResetStream()
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBracketRight) {
+			generator.AdjustInterval(-10)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+			generator.AdjustInterval(10)
+		}
+	}
+
+	// Configure the selected iterator
+	if iterator, ok := w.SelectedEntity.(*entities.Iterator); ok && !w.BuildMode {
+		if input.IsKeyJustPressed(ebiten.KeyM) {
+			iterator.ToggleMode()
+		}
+		if input.IsKeyJustPressed(ebiten.KeyEqual) {
+			iterator.AdjustMaxIterations(10)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyMinus) {
+			iterator.AdjustMaxIterations(-10)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyF) && iterator.UsesFormula() {
+			w.FormulaEdit = &FormulaEditor{Target: iterator, Text: iterator.Formula.Source}
+			return
+		}
+	}
+
+	// Configure the selected accumulator
+	if accumulator, ok := w.SelectedEntity.(*entities.Accumulator); ok && !w.BuildMode {
+		if input.IsKeyJustPressed(ebiten.KeyEqual) {
+			accumulator.AdjustWindow(1)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyMinus) {
+			accumulator.AdjustWindow(-1)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBracketRight) {
+			accumulator.AdjustInterval(-10)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+			accumulator.AdjustInterval(10)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyT) {
+			accumulator.Trigger()
+		}
+	}
+
+	// Configure the selected void
+	if void, ok := w.SelectedEntity.(*entities.Void); ok && !w.BuildMode {
+		if input.IsKeyJustPressed(ebiten.KeyEqual) {
+			void.CycleFilter(1)
+		}
+		if input.IsKeyJustPressed(ebiten.KeyMinus) {
+			void.CycleFilter(-1)
+		}
+	}
+
+	// Insert and remove modules in the selected building
+	if !w.BuildMode && w.SelectedEntity != nil {
+		for i, key := range moduleKeys {
+			if i < len(w.Registry.Modules) && input.IsKeyJustPressed(key) {
+				w.tryInsertModule(w.SelectedEntity, w.Registry.Modules[i])
+			}
+		}
+		if input.IsKeyJustPressed(ebiten.KeyBackspace) {
+			if slots, _ := moduleSlotsOf(w.SelectedEntity); slots != nil {
+				slots.Remove()
+			}
 		}
 	}
 
@@ -160,29 +385,124 @@ func (w *World) HandleInput(input *InputManager, camera *Camera) {
 	w.generateAroundCamera(camera)
 }
 
-// tryPlaceBuilding attempts to place a building at the given position
-func (w *World) tryPlaceBuilding(pos entities.GridPosition) {
-	switch w.SelectedBuilding {
-	case BuildingMiner:
-		w.tryPlaceMiner(pos)
+// handleFormulaInput edits the formula of the iterator being configured
+func (w *World) handleFormulaInput(input *InputManager) {
+	edit := w.FormulaEdit
+	edit.Text += string(input.AppendInputChars(nil))
+
+	if input.IsKeyJustPressed(ebiten.KeyBackspace) && len(edit.Text) > 0 {
+		runes := []rune(edit.Text)
+		edit.Text = string(runes[:len(runes)-1])
+	}
+	if input.IsKeyJustPressed(ebiten.KeyEscape) {
+		w.FormulaEdit = nil
+		return
+	}
+	if input.IsKeyJustPressed(ebiten.KeyEnter) {
+		if err := edit.Target.SetFormula(edit.Text); err != nil {
+			edit.Error = err.Error()
+			return
+		}
+		w.FormulaEdit = nil
 	}
 }
 
-// tryPlaceMiner attempts to place a miner at the given position
-func (w *World) tryPlaceMiner(pos entities.GridPosition) {
-	// Check if position is occupied
-	if w.isPositionOccupied(pos) {
+// numberKeys are the keys 1 to 9
+var numberKeys = []ebiten.Key{
+	ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5,
+	ebiten.Key6, ebiten.Key7, ebiten.Key8, ebiten.Key9,
+}
+
+// buildKeys select the building kind with the matching BuildKinds index in
+// build mode, and moduleKeys insert the module with the matching registry
+// index into the inspected building
+var buildKeys, moduleKeys = numberKeys, numberKeys
+
+// moduleSlotsOf returns the module slots of a building and the kind modules must fit
+func moduleSlotsOf(entity entities.Entity) (*entities.ModuleSlots, string) {
+	switch e := entity.(type) {
+	case *entities.Miner:
+		return &e.Modules, entities.FitsMiner
+	case *entities.Processor:
+		return &e.Modules, entities.FitsProcessor
+	case *entities.Generator:
+		return &e.Modules, entities.FitsGenerator
+	case *entities.Iterator:
+		return &e.Modules, entities.FitsIterator
+	default:
+		return nil, ""
+	}
+}
+
+// tryInsertModule pays for a module and slots it into a building.
+// Removed modules are destroyed, so the cost is not refunded.
+func (w *World) tryInsertModule(entity entities.Entity, def *entities.ModuleDef) {
+	slots, kind := moduleSlotsOf(entity)
+	if slots == nil || !def.FitsIn(kind) || len(slots.Modules) >= slots.Capacity {
 		return
 	}
+	if !w.Core.Spend(def.Cost) {
+		return
+	}
+	slots.Insert(def)
+}
+
+// BuildKinds lists what build mode can place, in key order: miners, then
+// every building kind in the registry
+func (w *World) BuildKinds() []entities.BuildingKind {
+	return append([]entities.BuildingKind{entities.KindMiner}, w.Registry.Kinds...)
+}
+
+// SelectedDef returns the definition chosen for placement, or nil when
+// placing miners
+func (w *World) SelectedDef() entities.BuildingDef {
+	defs := w.Registry.Buildings(w.SelectedKind)
+	if len(defs) == 0 {
+		return nil
+	}
+	return defs[w.SelectedDefs[w.SelectedKind]]
+}
 
-	// Check if there's a deposit at this position
-	deposit, exists := w.Deposits[pos]
-	if !exists || !deposit.CanBeMined() {
+// tryPlaceBuilding attempts to place the selected building at the given
+// position, paying its cost to the Core
+func (w *World) tryPlaceBuilding(pos entities.GridPosition) {
+	if !w.canPlaceAt(pos) {
+		return
+	}
+
+	def := w.SelectedDef()
+	if def == nil {
+		w.placeMiner(pos)
+		return
+	}
+	if !w.Core.Spend(def.Base().Cost) {
 		return
 	}
 
-	// Create miner with default output direction (right)
-	miner := entities.NewMiner(pos.X, pos.Y, deposit, entities.DirectionRight)
+	building := def.Build(pos.X, pos.Y, w.PlacementDir)
+	if worker, ok := building.(entities.Worker); ok {
+		worker.ScaleWorkTime(w.terrainAt(pos).WorkTime)
+	}
+	if seeded, ok := building.(entities.Seeded); ok {
+		seeded.SeedFrom(w.Generation.Seed)
+	}
+	if spanner, ok := building.(entities.Spanner); ok {
+		spanner.SpanOver(func(pos entities.GridPosition) bool {
+			return w.terrainAt(pos).Crossable()
+		})
+	}
+
+	w.Buildings = append(w.Buildings, building)
+	w.placeEntity(building)
+}
+
+// placeMiner places a miner on the deposit at the given position
+func (w *World) placeMiner(pos entities.GridPosition) {
+	deposit := w.Deposits[pos]
+
+	// Create miner facing the current placement direction
+	miner := entities.NewMiner(pos.X, pos.Y, deposit, w.PlacementDir)
+	miner.ScaleWorkTime(w.terrainAt(pos).WorkTime)
 	deposit.SetMined(true)
 
 	// Add to world
@@ -192,15 +512,19 @@ func (w *World) tryPlaceMiner(pos entities.GridPosition) {
 
 // Draw renders the world
 func (w *World) Draw(screen *ebiten.Image, camera *Camera) {
+	w.drawZones(screen, camera)
 	w.drawGrid(screen, camera)
 	w.drawDeposits(screen, camera)
 	w.drawEntities(screen, camera)
 	w.drawNumbers(screen, camera)
+	w.drawSelection(screen, camera)
 	w.drawBuildPreview(screen, camera)
 }
 
-// drawGrid draws the world grid
+// drawGrid draws the terrain and the world grid
 func (w *World) drawGrid(screen *ebiten.Image, camera *Camera) {
+	w.drawTerrain(screen, camera)
+
 	if camera.GetZoom() < 0.5 {
 		return // Don't draw grid when zoomed out too much
 	}
@@ -236,6 +560,79 @@ func (w *World) drawGrid(screen *ebiten.Image, camera *Camera) {
 	}
 }
 
+// drawTerrain fills visible tiles that are not open ground. Rough ground
+// gets a scatter of pebbles and rifts a glowing core, when zoomed in enough.
+func (w *World) drawTerrain(screen *ebiten.Image, camera *Camera) {
+	startX, endX, startY, endY := visibleTiles(screen, camera)
+	size := float32(TileSize * camera.GetZoom())
+
+	for x := startX; x <= endX; x++ {
+		for y := startY; y <= endY; y++ {
+			pos := entities.GridPosition{X: x, Y: y}
+			terrain := w.terrainAt(pos)
+			if terrain == systems.TerrainOpen {
+				continue
+			}
+			worldX, worldY := pos.ToWorldPos()
+			screenX, screenY := camera.WorldToScreen(worldX, worldY)
+			sx, sy := float32(screenX), float32(screenY)
+			vector.DrawFilledRect(screen, sx, sy, size, size, terrain.Color(), false)
+			if size < 8 {
+				continue
+			}
+
+			switch terrain {
+			case systems.TerrainRough:
+				pebble := color.RGBA{90, 80, 60, 200}
+				for _, offset := range [][2]float32{{0.25, 0.3}, {0.7, 0.2}, {0.5, 0.65}, {0.2, 0.8}, {0.8, 0.75}} {
+					vector.DrawFilledCircle(screen, sx+offset[0]*size, sy+offset[1]*size, size/16, pebble, false)
+				}
+			case systems.TerrainInfinityRift:
+				vector.DrawFilledCircle(screen, sx+size/2, sy+size/2, size/6, color.RGBA{200, 80, 255, 255}, false)
+			}
+		}
+	}
+}
+
+// drawZones tints visible tiles with the color of their zone
+func (w *World) drawZones(screen *ebiten.Image, camera *Camera) {
+	startX, endX, startY, endY := visibleTiles(screen, camera)
+	size := float32(TileSize * camera.GetZoom())
+
+	for x := startX; x <= endX; x++ {
+		for y := startY; y <= endY; y++ {
+			tint := w.zoneAt(entities.GridPosition{X: x, Y: y}).Tint()
+			if tint.A == 0 {
+				continue
+			}
+			worldX, worldY := entities.GridPosition{X: x, Y: y}.ToWorldPos()
+			screenX, screenY := camera.WorldToScreen(worldX, worldY)
+			vector.DrawFilledRect(screen, float32(screenX), float32(screenY), size, size, tint, false)
+		}
+	}
+}
+
+// visibleTiles returns the range of tiles the camera shows
+func visibleTiles(screen *ebiten.Image, camera *Camera) (startX, endX, startY, endY int) {
+	screenW, screenH := screen.Bounds().Dx(), screen.Bounds().Dy()
+	zoom := camera.GetZoom()
+	startX = int(math.Floor((camera.X - float64(screenW)/(2*zoom)) / TileSize))
+	endX = int(math.Floor((camera.X + float64(screenW)/(2*zoom)) / TileSize))
+	startY = int(math.Floor((camera.Y - float64(screenH)/(2*zoom)) / TileSize))
+	endY = int(math.Floor((camera.Y + float64(screenH)/(2*zoom)) / TileSize))
+	return startX, endX, startY, endY
+}
+
+// zoneAt returns the zone of a tile, caching it for later frames
+func (w *World) zoneAt(pos entities.GridPosition) systems.ZoneType {
+	zone, ok := w.Zones[pos]
+	if !ok {
+		zone = w.Generation.ZoneAt(pos.X, pos.Y)
+		w.Zones[pos] = zone
+	}
+	return zone
+}
+
 // drawDeposits draws all number deposits
 func (w *World) drawDeposits(screen *ebiten.Image, camera *Camera) {
 	for _, deposit := range w.Deposits {
@@ -257,6 +654,21 @@ func (w *World) drawNumbers(screen *ebiten.Image, camera *Camera) {
 	}
 }
 
+// drawSelection outlines the building being inspected
+func (w *World) drawSelection(screen *ebiten.Image, camera *Camera) {
+	if w.SelectedEntity == nil || w.BuildMode {
+		return
+	}
+
+	worldX, worldY := w.SelectedEntity.GetGridPosition().ToWorldPos()
+	screenX, screenY := camera.WorldToScreen(worldX, worldY)
+	sizeX, sizeY := w.SelectedEntity.GetSize()
+	zoom := float32(camera.GetZoom())
+
+	vector.StrokeRect(screen, float32(screenX), float32(screenY),
+		float32(sizeX*TileSize)*zoom, float32(sizeY*TileSize)*zoom, 2, color.RGBA{255, 255, 0, 255}, false)
+}
+
 // drawBuildPreview draws building placement preview
 func (w *World) drawBuildPreview(screen *ebiten.Image, camera *Camera) {
 	if !w.BuildMode {
@@ -278,12 +690,21 @@ func (w *World) drawBuildPreview(screen *ebiten.Image, camera *Camera) {
 		size, size, previewColor, false)
 }
 
-// generateArea generates deposits in the specified area
+// generateArea generates terrain and deposits in the specified area.
+// Tiles that cannot be built on hold no deposits.
 func (w *World) generateArea(startX, startY, width, height int) {
 	for x := startX; x < startX+width; x++ {
 		for y := startY; y < startY+height; y++ {
 			pos := entities.GridPosition{X: x, Y: y}
 
+			terrain := w.Generation.TerrainAt(x, y)
+			if terrain != systems.TerrainOpen {
+				w.Terrain[pos] = terrain
+			}
+			if !terrain.Buildable() {
+				continue
+			}
+
 			// Skip if already has deposit or is occupied by core
 			if _, exists := w.Deposits[pos]; exists {
 				continue
@@ -292,72 +713,13 @@ func (w *World) generateArea(startX, startY, width, height int) {
 				continue
 			}
 
-			// Generate deposit based on distance from origin and randomness
-			if w.shouldGenerateDeposit(x, y) {
-				value := w.generateNumberForPosition(x, y)
-				infinite := w.shouldBeInfinite(x, y, value)
-				deposit := entities.NewNumberDeposit(x, y, value, infinite)
+			if deposit := w.Generation.DepositAt(x, y); deposit != nil {
 				w.Deposits[pos] = deposit
 			}
 		}
 	}
 }
 
-// shouldGenerateDeposit determines if a deposit should be generated at this position
-func (w *World) shouldGenerateDeposit(x, y int) bool {
-	// Don't generate too close to origin
-	if x >= -1 && x <= 2 && y >= -1 && y <= 2 {
-		return false
-	}
-
-	// Use better random seed based on position
-	// Create a proper pseudo-random number for this position
-	seed := int64(x*1000000 + y*1000 + 12345) // Better seed combination
-	rng := rand.New(rand.NewSource(seed))
-
-	// 15% chance for deposits
-	return rng.Float64() < 0.15
-}
-
-// generateNumberForPosition generates an appropriate number for the given position
-func (w *World) generateNumberForPosition(x, y int) int {
-	// Calculate distance from origin (0,0)
-	distanceFromOrigin := math.Sqrt(float64(x*x + y*y))
-
-	// Use the same position-based random seed for consistency
-	seed := int64(x*1000000 + y*1000 + 12345)
-	rng := rand.New(rand.NewSource(seed))
-
-	if distanceFromOrigin < 3 {
-		// Very close to origin: tiny numbers (1-5)
-		return rng.Intn(5) + 1
-	} else if distanceFromOrigin < 8 {
-		// Close to origin: small numbers (1-20)
-		return rng.Intn(20) + 1
-	} else if distanceFromOrigin < 15 {
-		// Medium distance: medium numbers (5-100)
-		return rng.Intn(96) + 5
-	} else if distanceFromOrigin < 25 {
-		// Far distance: larger numbers (20-500)
-		return rng.Intn(481) + 20
-	} else {
-		// Very far: huge numbers and more primes
-		maxValue := int(distanceFromOrigin * 50) // Numbers scale with distance
-
-		return generatePrimeInRange(50, maxValue, rng)
-	}
-}
-
-// shouldBeInfinite determines if a deposit should be infinite
-func (w *World) shouldBeInfinite(x, y int, value int) bool {
-	// Small chance for infinite deposits, higher for primes
-	baseChance := 0.05
-	if entities.IsPrime(value) {
-		baseChance = 0.15
-	}
-	return rand.Float64() < baseChance
-}
-
 // generateAroundCamera generates world chunks around the camera
 func (w *World) generateAroundCamera(camera *Camera) {
 	chunkX := int(camera.X) / (ChunkSize * TileSize)
@@ -388,13 +750,26 @@ func (w *World) isPositionOccupied(pos entities.GridPosition) bool {
 	return occupied || w.Core.OccupiesPosition(pos)
 }
 
+// canPlaceAt reports whether the selected building can be placed at a tile:
+// miners need a deposit, other buildings need the Core to afford them
 func (w *World) canPlaceAt(pos entities.GridPosition) bool {
-	switch w.SelectedBuilding {
-	case BuildingMiner:
-		return !w.isPositionOccupied(pos) && w.hasDepositAt(pos)
-	default:
-		return !w.isPositionOccupied(pos)
+	if !w.canBuildAt(pos) {
+		return false
+	}
+	if def := w.SelectedDef(); def != nil {
+		return w.Core.CanAfford(def.Base().Cost)
 	}
+	return w.hasDepositAt(pos)
+}
+
+// canBuildAt reports whether a tile is free and its terrain takes buildings
+func (w *World) canBuildAt(pos entities.GridPosition) bool {
+	return !w.isPositionOccupied(pos) && w.terrainAt(pos).Buildable()
+}
+
+// terrainAt returns the terrain of a generated tile
+func (w *World) terrainAt(pos entities.GridPosition) systems.TerrainType {
+	return w.Terrain[pos]
 }
 
 func (w *World) hasDepositAt(pos entities.GridPosition) bool {
@@ -402,6 +777,14 @@ func (w *World) hasDepositAt(pos entities.GridPosition) bool {
 	return exists && deposit.CanBeMined()
 }
 
+// cycleIndex steps through n choices, wrapping around at both ends
+func cycleIndex(index, step, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return ((index+step)%n + n) % n
+}
+
 func (w *World) placeEntity(entity entities.Entity) {
 	pos := entity.GetGridPosition()
 	sizeX, sizeY := entity.GetSize()
@@ -415,27 +798,44 @@ func (w *World) placeEntity(entity entities.Entity) {
 	}
 }
 
-// Update this function to accept an RNG parameter
-func generatePrimeInRange(min, max int, rng *rand.Rand) int {
-	if min > max || min < 2 {
-		return 2
-	}
-
-	// Try to find a prime in the range
-	for attempts := 0; attempts < 100; attempts++ {
-		candidate := rng.Intn(max-min+1) + min
-		if entities.IsPrime(candidate) {
-			return candidate
+// HoverText describes the floating number or deposit at a world position
+// with its full value, or returns "" if there is nothing there
+func (w *World) HoverText(worldX, worldY float64) string {
+	for i := len(w.Numbers) - 1; i >= 0; i-- {
+		number := w.Numbers[i]
+		if math.Hypot(number.X-worldX, number.Y-worldY) > number.Size/2 {
+			continue
+		}
+		if number.IsBundle() {
+			return number.Label()
 		}
+		if !number.IsInteger() {
+			if number.Rational().IsProper() {
+				return number.Label() + " (proper fraction)"
+			}
+			return number.Label() + " (improper fraction)"
+		}
+		return fmt.Sprintf("%s (%s)", number.Label(), strings.Join(number.Tags.Names(), ", "))
 	}
 
-	// Fallback: find next prime after min
-	for candidate := min; candidate <= max; candidate++ {
-		if entities.IsPrime(candidate) {
-			return candidate
+	pos := entities.WorldPosToGrid(worldX, worldY)
+	deposit, exists := w.Deposits[pos]
+	if !exists {
+		if terrain := w.terrainAt(pos); terrain != systems.TerrainOpen {
+			return terrain.Name()
 		}
+		return ""
+	}
+	text := fmt.Sprintf("Deposit %s (%s)", entities.FormatValue(deposit.NumberValue), strings.Join(deposit.Tags.Names(), ", "))
+	if deposit.IsInfinite {
+		text += ", infinite"
+	} else {
+		text += fmt.Sprintf(", %d left", deposit.RemainingOre)
+	}
+	if terrain := w.terrainAt(pos); terrain != systems.TerrainOpen {
+		text += ", " + terrain.Name()
 	}
-	return 2 // Ultimate fallback
+	return text + ", " + w.zoneAt(deposit.Position).Name()
 }
 
 // GetStats returns world statistics
@@ -0,0 +1,80 @@
+package math
+
+// The classic arithmetic functions are defined for n >= 1; each reports false
+// outside that domain or when the result does not fit in an int.
+
+// Totient returns Euler's φ(n), the count of 1..n coprime to n
+func Totient(n int) (int, bool) {
+	if n < 1 {
+		return 0, false
+	}
+	result := n
+	for _, f := range Factorize(n) {
+		result = result / f.Prime * (f.Prime - 1)
+	}
+	return result, true
+}
+
+// DivisorCount returns τ(n), the number of positive divisors of n
+func DivisorCount(n int) (int, bool) {
+	if n < 1 {
+		return 0, false
+	}
+	count := 1
+	for _, f := range Factorize(n) {
+		count *= f.Exp + 1
+	}
+	return count, true
+}
+
+// DivisorSum returns σ(n), the sum of the positive divisors of n
+func DivisorSum(n int) (int, bool) {
+	if n < 1 {
+		return 0, false
+	}
+	sum := 1
+	for _, f := range Factorize(n) {
+		// 1 + p + p^2 + ... + p^e
+		term, power := 1, 1
+		for i := 0; i < f.Exp; i++ {
+			var ok bool
+			if power, ok = MulChecked(power, f.Prime); !ok {
+				return 0, false
+			}
+			if term, ok = AddChecked(term, power); !ok {
+				return 0, false
+			}
+		}
+		var ok bool
+		if sum, ok = MulChecked(sum, term); !ok {
+			return 0, false
+		}
+	}
+	return sum, true
+}
+
+// Mobius returns μ(n): 0 if a square divides n, otherwise (-1)^k for k prime factors
+func Mobius(n int) (int, bool) {
+	if n < 1 {
+		return 0, false
+	}
+	factors := Factorize(n)
+	for _, f := range factors {
+		if f.Exp > 1 {
+			return 0, true
+		}
+	}
+	if len(factors)%2 == 1 {
+		return -1, true
+	}
+	return 1, true
+}
+
+// LargestPrimeFactor returns the largest prime dividing n, failing for n < 2
+func LargestPrimeFactor(n int) (int, bool) {
+	if n < 2 {
+		return 0, false
+	}
+	factors := Factorize(n)
+	return factors[len(factors)-1].Prime, true
+}
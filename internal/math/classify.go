@@ -0,0 +1,80 @@
+package math
+
+import (
+	stdmath "math"
+	"strconv"
+)
+
+// IsSquare reports whether n is a perfect square
+func IsSquare(n int) bool {
+	_, exact, ok := IntRoot(n, 2)
+	return ok && exact
+}
+
+// IsCube reports whether n is a perfect cube, including negative cubes
+func IsCube(n int) bool {
+	_, exact, ok := IntRoot(n, 3)
+	return ok && exact
+}
+
+// IsTriangular reports whether n = k(k+1)/2 for some k >= 0
+func IsTriangular(n int) bool {
+	if n < 0 {
+		return false
+	}
+	// k is within one of sqrt(2n); the float error is far smaller than that
+	k := int(stdmath.Sqrt(2 * float64(n)))
+	for _, c := range []int{k - 1, k, k + 1} {
+		if t, ok := Triangular(c); ok && t == n {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFibonacci reports whether n appears in the Fibonacci sequence
+func IsFibonacci(n int) bool {
+	for i := 0; ; i++ {
+		f, ok := Fibonacci(i)
+		if !ok || f > n {
+			return false
+		}
+		if f == n {
+			return true
+		}
+	}
+}
+
+// IsPalindrome reports whether the decimal digits of |n| read the same both
+// ways. Single digits are not counted.
+func IsPalindrome(n int) bool {
+	digits := strconv.FormatUint(absUint(n), 10)
+	if len(digits) < 2 {
+		return false
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		if digits[i] != digits[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPerfect reports whether n equals the sum of its proper divisors
+func IsPerfect(n int) bool {
+	if n < 2 {
+		return false
+	}
+	sum, ok := DivisorSum(n)
+	return ok && sum-n == n
+}
+
+// IsTwinPrime reports whether n is a prime with another prime two away
+func IsTwinPrime(n int) bool {
+	return IsPrime(n) && (IsPrime(n-2) || n < stdmath.MaxInt-1 && IsPrime(n+2))
+}
+
+// IsMersennePrime reports whether n is a prime of the form 2^k - 1
+func IsMersennePrime(n int) bool {
+	return n > 0 && n&(n+1) == 0 && IsPrime(n)
+}
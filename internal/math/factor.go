@@ -0,0 +1,63 @@
+package math
+
+import "sort"
+
+// trialLimit bounds the small primes Factorize divides out before Pollard rho
+const trialLimit = 1000
+
+// PrimePower is one factor p^k of a factorization
+type PrimePower struct {
+	Prime int
+	Exp   int
+}
+
+// Factorize returns the prime factorization of |n| in ascending order of
+// primes. 0, 1 and -1 have no prime factors. Small primes are divided out
+// directly; whatever remains is split with Pollard rho.
+func Factorize(n int) []PrimePower {
+	m := absUint(n)
+	if m < 2 {
+		return nil
+	}
+
+	exps := make(map[uint64]int)
+	for p := uint64(2); p < trialLimit && p*p <= m; p++ {
+		if !IsPrime(int(p)) {
+			continue
+		}
+		for m%p == 0 {
+			m /= p
+			exps[p]++
+		}
+	}
+	if m > 1 {
+		splitFactor(m, exps)
+	}
+
+	factors := make([]PrimePower, 0, len(exps))
+	for p, exp := range exps {
+		factors = append(factors, PrimePower{Prime: int(p), Exp: exp})
+	}
+	sort.Slice(factors, func(i, j int) bool { return factors[i].Prime < factors[j].Prime })
+	return factors
+}
+
+// splitFactor records the prime factors of m > 1, which has no factors
+// below trialLimit except when it is small enough to be prime itself
+func splitFactor(m uint64, exps map[uint64]int) {
+	if IsPrime(int(m)) {
+		exps[m]++
+		return
+	}
+	d := pollardRho(m)
+	splitFactor(d, exps)
+	splitFactor(m/d, exps)
+}
+
+// absUint returns |n| without overflowing for MinInt
+func absUint(n int) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
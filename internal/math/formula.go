@@ -0,0 +1,211 @@
+package math
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// Formula is a parsed integer expression in the variable x.
+// It supports + - * / % ^, unary minus and parentheses; / truncates and
+// % returns a non-negative remainder for a positive modulus.
+type Formula struct {
+	Source string
+	root   formulaNode
+}
+
+type formulaNode interface {
+	eval(x int) (int, error)
+}
+
+type formulaConst int
+
+type formulaVar struct{}
+
+type formulaNeg struct {
+	operand formulaNode
+}
+
+type formulaBinary struct {
+	op          byte
+	left, right formulaNode
+}
+
+// ParseFormula compiles an expression such as "x*x + 1" or "(3*x+1) % 1000"
+func ParseFormula(source string) (*Formula, error) {
+	p := &formulaParser{src: source}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	return &Formula{Source: source, root: root}, nil
+}
+
+// Eval evaluates the formula for the given x
+func (f *Formula) Eval(x int) (int, error) {
+	return f.root.eval(x)
+}
+
+func (c formulaConst) eval(int) (int, error) {
+	return int(c), nil
+}
+
+func (formulaVar) eval(x int) (int, error) {
+	return x, nil
+}
+
+func (n formulaNeg) eval(x int) (int, error) {
+	v, err := n.operand.eval(x)
+	if err != nil {
+		return 0, err
+	}
+	return ApplyInt("neg", v)
+}
+
+// formulaOperations maps formula operators to registry operations
+var formulaOperations = map[byte]string{
+	'+': "add",
+	'-': "sub",
+	'*': "mul",
+	'/': "div",
+	'%': "mod",
+	'^': "pow",
+}
+
+func (b formulaBinary) eval(x int) (int, error) {
+	l, err := b.left.eval(x)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.right.eval(x)
+	if err != nil {
+		return 0, err
+	}
+	return ApplyInt(formulaOperations[b.op], l, r)
+}
+
+// formulaParser is a recursive descent parser over the grammar
+//
+//	expr  = term {("+" | "-") term}
+//	term  = unary {("*" | "/" | "%") unary}
+//	unary = "-" unary | power
+//	power = atom ["^" unary]
+//	atom  = number | "x" | "(" expr ")"
+type formulaParser struct {
+	src string
+	pos int
+}
+
+func (p *formulaParser) skipSpaces() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end
+func (p *formulaParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *formulaParser) parseExpr() (formulaNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseTerm() (formulaNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/' || op == '%'; op = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return formulaNeg{operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+func (p *formulaParser) parsePower() (formulaNode, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == '^' {
+		p.pos++
+		exp, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return formulaBinary{op: '^', left: base, right: exp}, nil
+	}
+	return base, nil
+}
+
+func (p *formulaParser) parseAtom() (formulaNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, errors.New("unexpected end of formula")
+	case c == 'x' || c == 'X':
+		p.pos++
+		return formulaVar{}, nil
+	case c == '(':
+		p.pos++
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		p.pos++
+		return inner, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		value, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil {
+			return nil, fmt.Errorf("number %q is too large", p.src[start:p.pos])
+		}
+		return formulaConst(value), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+	}
+}
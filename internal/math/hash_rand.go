@@ -0,0 +1,79 @@
+package math
+
+import "math/bits"
+
+// golden is the splitmix64 increment, 2^64 divided by the golden ratio
+const golden = 0x9e3779b97f4a7c15
+
+// HashRand is a stateless random source for world generation. Every draw
+// hashes the seed, a tile position and a salt naming what the value is for,
+// so it allocates nothing, does not depend on generation order and gives
+// unrelated values for different purposes on the same tile.
+type HashRand struct {
+	Seed uint64
+}
+
+// Uint64 returns the random value for a tile and purpose. Each input goes
+// through its own round of splitmix64, so unlike a linear seed formula,
+// coordinates cannot cancel each other out, negative ones included.
+func (r HashRand) Uint64(x, y int, salt uint64) uint64 {
+	h := splitmix64(r.Seed ^ splitmix64(salt))
+	h = splitmix64(h ^ uint64(x))
+	return splitmix64(h ^ uint64(y))
+}
+
+// Float64 returns a value in [0, 1) for a tile and purpose
+func (r HashRand) Float64(x, y int, salt uint64) float64 {
+	return unitFloat(r.Uint64(x, y, salt))
+}
+
+// Intn returns a value in [0, n) for a tile and purpose; n must be positive
+func (r HashRand) Intn(x, y int, salt uint64, n int) int {
+	return boundedInt(r.Uint64(x, y, salt), n)
+}
+
+// Stream starts a sequence of values for a tile and purpose, for draws that
+// need more than one value
+func (r HashRand) Stream(x, y int, salt uint64) HashStream {
+	return HashStream{state: r.Uint64(x, y, salt)}
+}
+
+// HashStream is a splitmix64 sequence seeded from one HashRand draw. It is a
+// plain value, so streams on the stack cost no allocation.
+type HashStream struct {
+	state uint64
+}
+
+// Uint64 returns the next value of the stream
+func (s *HashStream) Uint64() uint64 {
+	s.state += golden
+	return splitmix64(s.state)
+}
+
+// Float64 returns the next value of the stream in [0, 1)
+func (s *HashStream) Float64() float64 {
+	return unitFloat(s.Uint64())
+}
+
+// Intn returns the next value of the stream in [0, n); n must be positive
+func (s *HashStream) Intn(n int) int {
+	return boundedInt(s.Uint64(), n)
+}
+
+// splitmix64 is the finalizer of the splitmix64 generator
+func splitmix64(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// unitFloat maps the top 53 bits of h to [0, 1)
+func unitFloat(h uint64) float64 {
+	return float64(h>>11) / (1 << 53)
+}
+
+// boundedInt maps h to [0, n) by taking the high word of h*n
+func boundedInt(h uint64, n int) int {
+	hi, _ := bits.Mul64(h, uint64(n))
+	return int(hi)
+}
@@ -0,0 +1,28 @@
+package math
+
+// CollatzStep returns n/2 for even n and 3n+1 for odd n
+func CollatzStep(n int) (int, error) {
+	if n%2 == 0 {
+		return n / 2, nil
+	}
+	tripled, ok := MulChecked(n, 3)
+	if !ok {
+		return 0, ErrOverflow
+	}
+	next, ok := AddChecked(tripled, 1)
+	if !ok {
+		return 0, ErrOverflow
+	}
+	return next, nil
+}
+
+// DigitSquareSum returns the sum of the squares of the decimal digits of n
+func DigitSquareSum(n int) (int, error) {
+	sum := 0
+	for n != 0 {
+		d := n % 10
+		sum += d * d
+		n /= 10
+	}
+	return sum, nil
+}
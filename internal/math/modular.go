@@ -0,0 +1,66 @@
+package math
+
+import "math/bits"
+
+// Mod returns a mod n in the range [0, n), also for negative a
+func Mod(a, n int) (int, bool) {
+	if n <= 0 {
+		return 0, false
+	}
+	r := a % n
+	if r < 0 {
+		r += n
+	}
+	return r, true
+}
+
+// ModPow computes base^exp mod n using square-and-multiply
+func ModPow(base, exp, n int) (int, bool) {
+	if n <= 0 || exp < 0 {
+		return 0, false
+	}
+	if n == 1 {
+		return 0, true
+	}
+
+	b, _ := Mod(base, n)
+	result := uint64(1)
+	x := uint64(b)
+	m := uint64(n)
+	for e := uint64(exp); e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = mulMod(result, x, m)
+		}
+		x = mulMod(x, x, m)
+	}
+	return int(result), true
+}
+
+// ModInverse returns x such that a*x ≡ 1 (mod n), if one exists
+func ModInverse(a, n int) (int, bool) {
+	if n <= 0 {
+		return 0, false
+	}
+	r, _ := Mod(a, n)
+
+	// Extended Euclid on (r, n)
+	oldR, curR := r, n
+	oldS, curS := 1, 0
+	for curR != 0 {
+		q := oldR / curR
+		oldR, curR = curR, oldR-q*curR
+		oldS, curS = curS, oldS-q*curS
+	}
+	if oldR != 1 {
+		return 0, false
+	}
+	inv, _ := Mod(oldS, n)
+	return inv, true
+}
+
+// mulMod computes a*b mod m without overflowing, given a, b < m
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, rem := bits.Div64(hi, lo, m)
+	return rem
+}
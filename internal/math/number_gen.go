@@ -1 +1,131 @@
-package math
\ No newline at end of file
+package math
+
+import (
+	stdmath "math"
+	"math/bits"
+)
+
+// Sequence is an integer sequence indexed from 0
+type Sequence struct {
+	ID   string
+	Name string
+	// Term returns the n-th term, or false if it does not fit in an int
+	Term func(n int) (int, bool)
+}
+
+// Sequences lists every sequence generator buildings can emit
+var Sequences = []Sequence{
+	{ID: "fibonacci", Name: "Fibonacci", Term: Fibonacci},
+	{ID: "triangular", Name: "Triangular", Term: Triangular},
+	{ID: "powers_of_two", Name: "Powers of two", Term: PowerOfTwo},
+	{ID: "primes", Name: "Primes", Term: NthPrime},
+	{ID: "catalan", Name: "Catalan", Term: Catalan},
+}
+
+// SequenceByID looks up a sequence by its id
+func SequenceByID(id string) (Sequence, bool) {
+	for _, seq := range Sequences {
+		if seq.ID == id {
+			return seq, true
+		}
+	}
+	return Sequence{}, false
+}
+
+// Fibonacci returns F(n) with F(0) = 0, F(1) = 1
+func Fibonacci(n int) (int, bool) {
+	// F(92) is the largest Fibonacci number that fits in an int64
+	if n < 0 || n > 92 {
+		return 0, false
+	}
+	a, b := uint64(0), uint64(1)
+	for i := 0; i < n; i++ {
+		a, b = b, a+b
+	}
+	return int(a), true
+}
+
+// Triangular returns n(n+1)/2
+func Triangular(n int) (int, bool) {
+	if n < 0 {
+		return 0, false
+	}
+	hi, lo := bits.Mul64(uint64(n), uint64(n+1))
+	if hi != 0 {
+		return 0, false
+	}
+	return int(lo / 2), true
+}
+
+// PowerOfTwo returns 2^n
+func PowerOfTwo(n int) (int, bool) {
+	if n < 0 || n > 62 {
+		return 0, false
+	}
+	return 1 << n, true
+}
+
+// primeCache holds the primes found so far, in order
+var primeCache = []int{2, 3}
+
+// NthPrime returns the n-th prime, starting with NthPrime(0) = 2
+func NthPrime(n int) (int, bool) {
+	if n < 0 {
+		return 0, false
+	}
+	for len(primeCache) <= n {
+		candidate := primeCache[len(primeCache)-1] + 2
+		for !IsPrime(candidate) {
+			candidate += 2
+		}
+		primeCache = append(primeCache, candidate)
+	}
+	return primeCache[n], true
+}
+
+// Catalan returns the n-th Catalan number, C(0) = 1
+func Catalan(n int) (int, bool) {
+	if n < 0 {
+		return 0, false
+	}
+	// C(k+1) = C(k) * 2(2k+1) / (k+2), exact at every step
+	c := uint64(1)
+	for k := 0; k < n; k++ {
+		hi, lo := bits.Mul64(c, uint64(2*(2*k+1)))
+		if hi >= uint64(k+2) {
+			return 0, false
+		}
+		c, _ = bits.Div64(hi, lo, uint64(k+2))
+		if c > stdmath.MaxInt {
+			return 0, false
+		}
+	}
+	return int(c), true
+}
+
+// UlamIndex returns the number at (x, y) on the Ulam spiral, with 1 at the
+// origin, 2 to its right and the spiral turning counterclockwise with y
+// pointing up. It reports false when the number does not fit in an int.
+func UlamIndex(x, y int) (int, bool) {
+	ring := max(absUint(x), absUint(y))
+	if ring == 0 {
+		return 1, true
+	}
+	if ring > 1<<30 {
+		return 0, false
+	}
+	k := int(ring)
+
+	// The ring at distance k starts after (2k-1)^2 at (k, 1-k)
+	start := (2*k - 1) * (2*k - 1)
+	switch {
+	case x == k && y > -k:
+		return start + y + k, true
+	case y == k:
+		return start + 3*k - x, true
+	case x == -k:
+		return start + 5*k - y, true
+	default:
+		return start + 7*k + x, true
+	}
+}
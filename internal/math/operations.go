@@ -1 +1,456 @@
-package math
\ No newline at end of file
+package math
+
+import (
+	"errors"
+	"fmt"
+	stdmath "math"
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+// ErrOverflow is returned when a result does not fit in an int
+var ErrOverflow = errors.New("result does not fit in an int")
+
+// AddChecked returns a+b, reporting false on overflow
+func AddChecked(a, b int) (int, bool) {
+	if (b > 0 && a > stdmath.MaxInt-b) || (b < 0 && a < stdmath.MinInt-b) {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// SubChecked returns a-b, reporting false on overflow
+func SubChecked(a, b int) (int, bool) {
+	if (b < 0 && a > stdmath.MaxInt+b) || (b > 0 && a < stdmath.MinInt+b) {
+		return 0, false
+	}
+	return a - b, true
+}
+
+// MulChecked returns a*b, reporting false on overflow
+func MulChecked(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == stdmath.MinInt) || (b == -1 && a == stdmath.MinInt) {
+		return 0, false
+	}
+	p := a * b
+	if p/b != a {
+		return 0, false
+	}
+	return p, true
+}
+
+// PowChecked returns base^exp for exp >= 0, reporting false on overflow
+func PowChecked(base, exp int) (int, bool) {
+	if exp < 0 {
+		return 0, false
+	}
+	switch base {
+	case 0:
+		if exp == 0 {
+			return 1, true
+		}
+		return 0, true
+	case 1:
+		return 1, true
+	case -1:
+		if exp%2 == 0 {
+			return 1, true
+		}
+		return -1, true
+	}
+	if exp > 63 {
+		return 0, false
+	}
+
+	result := 1
+	for i := 0; i < exp; i++ {
+		var ok bool
+		if result, ok = MulChecked(result, base); !ok {
+			return 0, false
+		}
+	}
+	return result, true
+}
+
+// MaxBits bounds the size of arbitrary precision results; anything
+// estimated to be larger fails with ErrTooLarge instead of being computed
+const MaxBits = 1 << 14
+
+// ErrTooLarge is returned when a result would exceed MaxBits
+var ErrTooLarge = fmt.Errorf("result would exceed %d bits", MaxBits)
+
+// Operation is a named integer operation. Check rejects inputs outside its
+// domain, Eval computes the result and reports false when it does not fit
+// in an int. Operations that can outgrow an int set EvalBig, which computes
+// the exact result, and Bits, an upper bound on its bit length.
+type Operation struct {
+	Name    string
+	Arity   int
+	Check   func(args []int) error
+	Eval    func(args []int) (int, bool)
+	EvalBig func(args []*big.Int) *big.Int
+	Bits    func(args []int) int
+}
+
+// Value is the result of an operation: an int when it fits, otherwise an
+// arbitrary precision integer
+type Value struct {
+	small int
+	big   *big.Int
+}
+
+// IntValue wraps an int
+func IntValue(n int) Value {
+	return Value{small: n}
+}
+
+// BigValue wraps an arbitrary precision integer, keeping it small if it fits
+func BigValue(n *big.Int) Value {
+	if n.IsInt64() {
+		return Value{small: int(n.Int64())}
+	}
+	return Value{big: n}
+}
+
+// Int returns the value if it fits in an int
+func (v Value) Int() (int, bool) {
+	return v.small, v.big == nil
+}
+
+// IsBig reports whether the value needed arbitrary precision
+func (v Value) IsBig() bool {
+	return v.big != nil
+}
+
+// Big returns the value as an arbitrary precision integer
+func (v Value) Big() *big.Int {
+	if v.big != nil {
+		return new(big.Int).Set(v.big)
+	}
+	return big.NewInt(int64(v.small))
+}
+
+func (v Value) String() string {
+	if v.big != nil {
+		return v.big.String()
+	}
+	return strconv.Itoa(v.small)
+}
+
+// Operations lists every operation processors and formulas can evaluate
+var Operations = []Operation{
+	{
+		Name: "add", Arity: 2,
+		Eval:    func(a []int) (int, bool) { return AddChecked(a[0], a[1]) },
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Add(a[0], a[1]) },
+		Bits:    func(a []int) int { return max(bitLen(a[0]), bitLen(a[1])) + 1 },
+	},
+	{
+		Name: "sub", Arity: 2,
+		Eval:    func(a []int) (int, bool) { return SubChecked(a[0], a[1]) },
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Sub(a[0], a[1]) },
+		Bits:    func(a []int) int { return max(bitLen(a[0]), bitLen(a[1])) + 1 },
+	},
+	{
+		Name: "mul", Arity: 2,
+		Eval:    func(a []int) (int, bool) { return MulChecked(a[0], a[1]) },
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Mul(a[0], a[1]) },
+		Bits:    func(a []int) int { return bitLen(a[0]) + bitLen(a[1]) },
+	},
+	{
+		// div truncates towards zero; only MinInt / -1 leaves the int range
+		Name: "div", Arity: 2,
+		Check: func(a []int) error {
+			if a[1] == 0 {
+				return errors.New("division by zero")
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) {
+			if a[0] == stdmath.MinInt && a[1] == -1 {
+				return 0, false
+			}
+			return a[0] / a[1], true
+		},
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Quo(a[0], a[1]) },
+		Bits:    func(a []int) int { return bitLen(a[0]) },
+	},
+	{
+		Name: "mod", Arity: 2,
+		Check: positiveModulus(1),
+		Eval:  func(a []int) (int, bool) { return Mod(a[0], a[1]) },
+	},
+	{
+		Name: "pow", Arity: 2,
+		Check: func(a []int) error {
+			if a[1] < 0 {
+				return fmt.Errorf("negative exponent %d", a[1])
+			}
+			return nil
+		},
+		Eval:    func(a []int) (int, bool) { return PowChecked(a[0], a[1]) },
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Exp(a[0], a[1], nil) },
+		Bits: func(a []int) int {
+			// Compare before multiplying so huge exponents cannot overflow
+			if a[1] > MaxBits {
+				return MaxBits + 1
+			}
+			return bitLen(a[0]) * a[1]
+		},
+	},
+	{
+		Name: "neg", Arity: 1,
+		Eval:    func(a []int) (int, bool) { return SubChecked(0, a[0]) },
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Neg(a[0]) },
+		Bits:    func(a []int) int { return bitLen(a[0]) },
+	},
+	{
+		Name: "abs", Arity: 1,
+		Eval: func(a []int) (int, bool) {
+			if a[0] >= 0 {
+				return a[0], true
+			}
+			return SubChecked(0, a[0])
+		},
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Abs(a[0]) },
+		Bits:    func(a []int) int { return bitLen(a[0]) },
+	},
+	{
+		Name: "fact", Arity: 1,
+		Check: func(a []int) error {
+			if a[0] < 0 {
+				return fmt.Errorf("factorial of negative %d", a[0])
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) {
+			result := 1
+			for i := 2; i <= a[0]; i++ {
+				var ok bool
+				if result, ok = MulChecked(result, i); !ok {
+					return 0, false
+				}
+			}
+			return result, true
+		},
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).MulRange(1, a[0].Int64()) },
+		Bits: func(a []int) int {
+			// log2(n!) <= n log2(n)
+			if a[0] > MaxBits {
+				return MaxBits + 1
+			}
+			return a[0] * bitLen(a[0])
+		},
+	},
+	{
+		// gcd is always non-negative, so gcd(MinInt, 0) = 2^63 needs a big result
+		Name: "gcd", Arity: 2,
+		Eval: func(a []int) (int, bool) {
+			g := gcdUint(absUint(a[0]), absUint(a[1]))
+			return int(g), g <= stdmath.MaxInt
+		},
+		EvalBig: func(a []*big.Int) *big.Int {
+			return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a[0]), new(big.Int).Abs(a[1]))
+		},
+		Bits: func(a []int) int { return 64 },
+	},
+	{
+		Name: "lcm", Arity: 2,
+		Eval: func(a []int) (int, bool) {
+			if a[0] == 0 || a[1] == 0 {
+				return 0, true
+			}
+			g := gcdUint(absUint(a[0]), absUint(a[1]))
+			hi, lo := bits.Mul64(absUint(a[0])/g, absUint(a[1]))
+			return int(lo), hi == 0 && lo <= stdmath.MaxInt
+		},
+		EvalBig: func(a []*big.Int) *big.Int {
+			x, y := new(big.Int).Abs(a[0]), new(big.Int).Abs(a[1])
+			g := new(big.Int).GCD(nil, nil, x, y)
+			if g.Sign() == 0 {
+				return g // both are zero
+			}
+			return x.Mul(x.Quo(x, g), y)
+		},
+		Bits: func(a []int) int { return bitLen(a[0]) + bitLen(a[1]) },
+	},
+	{
+		Name: "modpow", Arity: 3,
+		Check: func(a []int) error {
+			if a[1] < 0 {
+				return fmt.Errorf("negative exponent %d", a[1])
+			}
+			return positiveModulus(2)(a)
+		},
+		Eval: func(a []int) (int, bool) { return ModPow(a[0], a[1], a[2]) },
+	},
+	{
+		Name: "modinverse", Arity: 2,
+		Check: func(a []int) error {
+			if err := positiveModulus(1)(a); err != nil {
+				return err
+			}
+			if gcdUint(absUint(a[0]), uint64(a[1])) != 1 {
+				return fmt.Errorf("%d has no inverse mod %d", a[0], a[1])
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) { return ModInverse(a[0], a[1]) },
+	},
+	{
+		Name: "and", Arity: 2,
+		Eval: func(a []int) (int, bool) { return a[0] & a[1], true },
+	},
+	{
+		Name: "or", Arity: 2,
+		Eval: func(a []int) (int, bool) { return a[0] | a[1], true },
+	},
+	{
+		Name: "xor", Arity: 2,
+		Eval: func(a []int) (int, bool) { return a[0] ^ a[1], true },
+	},
+	{
+		// not flips the low width bits of a value that fits in them
+		Name: "not", Arity: 2,
+		Check: func(a []int) error {
+			if a[1] < 0 || a[1] > 62 {
+				return fmt.Errorf("width %d is outside 0..62", a[1])
+			}
+			if a[0] < 0 || a[0] >= 1<<a[1] {
+				return fmt.Errorf("%d does not fit in %d bits", a[0], a[1])
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) { return ^a[0] & (1<<a[1] - 1), true },
+	},
+	{
+		Name: "shl", Arity: 2,
+		Check: func(a []int) error {
+			if a[1] < 0 || a[1] > MaxBits {
+				return fmt.Errorf("shift %d is outside 0..%d", a[1], MaxBits)
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) {
+			if a[1] > 62 || (a[0]<<a[1])>>a[1] != a[0] {
+				return 0, false
+			}
+			return a[0] << a[1], true
+		},
+		EvalBig: func(a []*big.Int) *big.Int { return new(big.Int).Lsh(a[0], uint(a[1].Int64())) },
+		Bits:    func(a []int) int { return bitLen(a[0]) + a[1] },
+	},
+	{
+		Name: "shr", Arity: 2,
+		Check: func(a []int) error {
+			if a[1] < 0 {
+				return fmt.Errorf("negative shift %d", a[1])
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) { return a[0] >> a[1], true },
+	},
+	arithmeticOperation("totient", 1, Totient),
+	arithmeticOperation("tau", 1, DivisorCount),
+	arithmeticOperation("sigma", 1, DivisorSum),
+	arithmeticOperation("mobius", 1, Mobius),
+	arithmeticOperation("lpf", 2, LargestPrimeFactor),
+}
+
+// arithmeticOperation wraps an arithmetic function defined for n >= least
+func arithmeticOperation(name string, least int, fn func(int) (int, bool)) Operation {
+	return Operation{
+		Name: name, Arity: 1,
+		Check: func(a []int) error {
+			if a[0] < least {
+				return fmt.Errorf("%s is only defined for n >= %d, got %d", name, least, a[0])
+			}
+			return nil
+		},
+		Eval: func(a []int) (int, bool) { return fn(a[0]) },
+	}
+}
+
+// positiveModulus checks that argument i is a positive modulus
+func positiveModulus(i int) func(args []int) error {
+	return func(a []int) error {
+		if a[i] <= 0 {
+			return fmt.Errorf("modulus must be positive, got %d", a[i])
+		}
+		return nil
+	}
+}
+
+// OperationByName looks up an operation by its name
+func OperationByName(name string) (Operation, bool) {
+	for _, op := range Operations {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// Apply evaluates a named operation, promoting the result to arbitrary
+// precision when it does not fit in an int
+func Apply(name string, args ...int) (Value, error) {
+	op, err := prepare(name, args)
+	if err != nil {
+		return Value{}, err
+	}
+	if result, ok := op.Eval(args); ok {
+		return IntValue(result), nil
+	}
+	if op.EvalBig == nil {
+		return Value{}, ErrOverflow
+	}
+	if op.Bits != nil && op.Bits(args) > MaxBits {
+		return Value{}, ErrTooLarge
+	}
+
+	bigArgs := make([]*big.Int, len(args))
+	for i, arg := range args {
+		bigArgs[i] = big.NewInt(int64(arg))
+	}
+	return BigValue(op.EvalBig(bigArgs)), nil
+}
+
+// ApplyInt evaluates a named operation whose result must fit in an int,
+// failing with ErrOverflow otherwise
+func ApplyInt(name string, args ...int) (int, error) {
+	op, err := prepare(name, args)
+	if err != nil {
+		return 0, err
+	}
+	result, ok := op.Eval(args)
+	if !ok {
+		return 0, ErrOverflow
+	}
+	return result, nil
+}
+
+// prepare looks up an operation and validates its arguments
+func prepare(name string, args []int) (Operation, error) {
+	op, ok := OperationByName(name)
+	if !ok {
+		return Operation{}, fmt.Errorf("unknown operation %q", name)
+	}
+	if len(args) != op.Arity {
+		return Operation{}, fmt.Errorf("%s takes %d arguments, got %d", name, op.Arity, len(args))
+	}
+	if op.Check != nil {
+		if err := op.Check(args); err != nil {
+			return Operation{}, err
+		}
+	}
+	return op, nil
+}
+
+// bitLen returns the bit length of |n|
+func bitLen(n int) int {
+	return bits.Len64(absUint(n))
+}
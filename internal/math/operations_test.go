@@ -0,0 +1,150 @@
+package math
+
+import (
+	"errors"
+	stdmath "math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []int
+		want    string // decimal result
+		big     bool   // whether the result needed arbitrary precision
+		wantErr string // part of the expected error, "" for none
+	}{
+		// Arity and unknown names
+		{name: "add", args: []int{1}, wantErr: "add takes 2 arguments, got 1"},
+		{name: "fact", args: []int{1, 2}, wantErr: "fact takes 1 arguments, got 2"},
+		{name: "root", args: []int{4}, wantErr: `unknown operation "root"`},
+
+		// Domain checks
+		{name: "div", args: []int{1, 0}, wantErr: "division by zero"},
+		{name: "mod", args: []int{5, 0}, wantErr: "modulus must be positive"},
+		{name: "pow", args: []int{2, -1}, wantErr: "negative exponent"},
+		{name: "fact", args: []int{-1}, wantErr: "factorial of negative"},
+		{name: "modinverse", args: []int{2, 4}, wantErr: "no inverse"},
+		{name: "not", args: []int{8, 3}, wantErr: "does not fit in 3 bits"},
+		{name: "shl", args: []int{1, -1}, wantErr: "outside 0.."},
+		{name: "shl", args: []int{1, MaxBits + 1}, wantErr: "outside 0.."},
+		{name: "totient", args: []int{0}, wantErr: "only defined for n >= 1"},
+
+		// Results that fit in an int
+		{name: "add", args: []int{2, 3}, want: "5"},
+		{name: "pow", args: []int{2, 62}, want: "4611686018427387904"},
+		{name: "pow", args: []int{-3, 3}, want: "-27"},
+		{name: "pow", args: []int{0, 0}, want: "1"},
+		{name: "fact", args: []int{20}, want: "2432902008176640000"},
+		{name: "gcd", args: []int{-12, 18}, want: "6"},
+		{name: "gcd", args: []int{0, 0}, want: "0"},
+		{name: "lcm", args: []int{4, 6}, want: "12"},
+		{name: "lcm", args: []int{0, 5}, want: "0"},
+		{name: "shl", args: []int{3, 2}, want: "12"},
+		{name: "mod", args: []int{-7, 3}, want: "2"},
+		{name: "not", args: []int{5, 3}, want: "2"},
+
+		// Results promoted to arbitrary precision
+		{name: "add", args: []int{stdmath.MaxInt, 1}, want: "9223372036854775808", big: true},
+		{name: "mul", args: []int{stdmath.MinInt, -1}, want: "9223372036854775808", big: true},
+		{name: "div", args: []int{stdmath.MinInt, -1}, want: "9223372036854775808", big: true},
+		{name: "neg", args: []int{stdmath.MinInt}, want: "9223372036854775808", big: true},
+		{name: "abs", args: []int{stdmath.MinInt}, want: "9223372036854775808", big: true},
+		{name: "pow", args: []int{2, 64}, want: "18446744073709551616", big: true},
+		{name: "fact", args: []int{25}, want: "15511210043330985984000000", big: true},
+		{name: "gcd", args: []int{stdmath.MinInt, 0}, want: "9223372036854775808", big: true},
+		{name: "lcm", args: []int{stdmath.MaxInt, stdmath.MaxInt - 1}, want: "85070591730234615838173535747377725442", big: true},
+		{name: "shl", args: []int{1, 100}, want: "1267650600228229401496703205376", big: true},
+		{name: "shl", args: []int{-1, 64}, want: "-18446744073709551616", big: true},
+
+		// Results beyond MaxBits are refused instead of computed
+		{name: "pow", args: []int{3, MaxBits}, wantErr: "exceed"},
+		{name: "fact", args: []int{5000}, wantErr: "exceed"},
+	}
+	for _, tt := range tests {
+		got, err := Apply(tt.name, tt.args...)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Apply(%s, %v) error = %v, want one mentioning %q", tt.name, tt.args, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("Apply(%s, %v) unexpected error %v", tt.name, tt.args, err)
+		case got.String() != tt.want || got.IsBig() != tt.big:
+			t.Errorf("Apply(%s, %v) = %v (big %v), want %s (big %v)", tt.name, tt.args, got, got.IsBig(), tt.want, tt.big)
+		}
+	}
+}
+
+func TestApplyInt(t *testing.T) {
+	tests := []struct {
+		name string
+		args []int
+		want int
+		err  error
+	}{
+		{name: "mul", args: []int{1 << 31, 1 << 31}, want: 1 << 62},
+		{name: "mul", args: []int{stdmath.MaxInt, 2}, err: ErrOverflow},
+		{name: "sub", args: []int{stdmath.MinInt, 1}, err: ErrOverflow},
+		{name: "pow", args: []int{2, 63}, err: ErrOverflow},
+		{name: "fact", args: []int{21}, err: ErrOverflow},
+		{name: "gcd", args: []int{stdmath.MinInt, 0}, err: ErrOverflow},
+		{name: "lcm", args: []int{stdmath.MaxInt, stdmath.MaxInt - 1}, err: ErrOverflow},
+		{name: "lcm", args: []int{1 << 40, 3 << 30}, want: 3 << 40},
+		{name: "shl", args: []int{1, 63}, err: ErrOverflow},
+		{name: "shl", args: []int{1, 62}, want: 1 << 62},
+		{name: "shr", args: []int{-8, 1}, want: -4},
+	}
+	for _, tt := range tests {
+		got, err := ApplyInt(tt.name, tt.args...)
+		if !errors.Is(err, tt.err) || (tt.err == nil && got != tt.want) {
+			t.Errorf("ApplyInt(%s, %v) = %d, %v; want %d, %v", tt.name, tt.args, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestOperationsAgree checks that every operation with an arbitrary
+// precision form gives the same answer as its int form where both apply
+func TestOperationsAgree(t *testing.T) {
+	values := []int{-7, -1, 0, 1, 2, 3, 10, 1 << 20}
+	for _, op := range Operations {
+		if op.EvalBig == nil {
+			continue
+		}
+		forEachArgs(op.Arity, values, func(args []int) {
+			want, err := ApplyInt(op.Name, args...)
+			if err != nil {
+				return
+			}
+			if got, err := Apply(op.Name, args...); err != nil || got.String() != IntValue(want).String() {
+				t.Errorf("%s%v: Apply = %v, %v; ApplyInt = %d", op.Name, args, got, err, want)
+			}
+			bigArgs := make([]*big.Int, len(args))
+			for i, arg := range args {
+				bigArgs[i] = big.NewInt(int64(arg))
+			}
+			if got := op.EvalBig(bigArgs); !got.IsInt64() || got.Int64() != int64(want) {
+				t.Errorf("%s%v: EvalBig = %v, Eval = %d", op.Name, args, got, want)
+			}
+		})
+	}
+}
+
+// forEachArgs calls fn with every tuple of n values
+func forEachArgs(n int, values []int, fn func(args []int)) {
+	args := make([]int, n)
+	var fill func(i int)
+	fill = func(i int) {
+		if i == n {
+			fn(args)
+			return
+		}
+		for _, v := range values {
+			args[i] = v
+			fill(i + 1)
+		}
+	}
+	fill(0)
+}
@@ -0,0 +1,113 @@
+package math
+
+import "sort"
+
+// maxRootSearch bounds the constant term whose divisors IntegerRoots tries
+const maxRootSearch = 1 << 40
+
+// Polynomial holds integer coefficients, index i being the coefficient of x^i
+type Polynomial []int
+
+// NewPolynomial builds a polynomial from coefficients written highest degree
+// first, as in 1, -3, 2 for x^2 - 3x + 2
+func NewPolynomial(coefficients []int) Polynomial {
+	p := make(Polynomial, len(coefficients))
+	for i, c := range coefficients {
+		p[len(coefficients)-1-i] = c
+	}
+	return p.trim()
+}
+
+// trim drops zero leading coefficients, keeping at least the constant term
+func (p Polynomial) trim() Polynomial {
+	for len(p) > 1 && p[len(p)-1] == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// Degree returns the degree of the polynomial, 0 for constants
+func (p Polynomial) Degree() int {
+	return len(p.trim()) - 1
+}
+
+// IsZero reports whether every coefficient is zero
+func (p Polynomial) IsZero() bool {
+	for _, c := range p {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval computes p(x) with Horner's rule, reporting false on overflow
+func (p Polynomial) Eval(x int) (int, bool) {
+	result := 0
+	for i := len(p) - 1; i >= 0; i-- {
+		var ok bool
+		if result, ok = MulChecked(result, x); !ok {
+			return 0, false
+		}
+		if result, ok = AddChecked(result, p[i]); !ok {
+			return 0, false
+		}
+	}
+	return result, true
+}
+
+// IntegerRoots returns the distinct integer roots in ascending order. Any
+// integer root divides the lowest nonzero coefficient, so only its divisors
+// are tried. It fails for the zero polynomial, which every x solves, and
+// when that coefficient is too large to search.
+func (p Polynomial) IntegerRoots() ([]int, bool) {
+	if p.IsZero() {
+		return nil, false
+	}
+
+	var roots []int
+	low := 0
+	for p[low] == 0 {
+		low++
+	}
+	if low > 0 {
+		roots = append(roots, 0)
+	}
+
+	constant := p[low]
+	if constant < -maxRootSearch || constant > maxRootSearch {
+		return nil, false
+	}
+	reduced := p[low:]
+	for _, d := range Divisors(constant) {
+		for _, candidate := range []int{d, -d} {
+			// A candidate whose evaluation overflows is not counted as a root
+			if value, ok := reduced.Eval(candidate); ok && value == 0 {
+				roots = append(roots, candidate)
+			}
+		}
+	}
+	sort.Ints(roots)
+	return roots, true
+}
+
+// Divisors returns the positive divisors of |n| in ascending order, built
+// from its prime factorization. 0 has none; n must not be MinInt.
+func Divisors(n int) []int {
+	if n == 0 {
+		return nil
+	}
+	divisors := []int{1}
+	for _, f := range Factorize(n) {
+		count := len(divisors)
+		power := 1
+		for e := 0; e < f.Exp; e++ {
+			power *= f.Prime
+			for _, d := range divisors[:count] {
+				divisors = append(divisors, d*power)
+			}
+		}
+	}
+	sort.Ints(divisors)
+	return divisors
+}
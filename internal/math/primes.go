@@ -0,0 +1,176 @@
+package math
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+const (
+	// sieveSegment is how many values each sieve extension covers
+	sieveSegment = 1 << 16
+	// sieveLimit is where IsPrime switches from the sieve to Miller-Rabin
+	sieveLimit = 1 << 24
+)
+
+// The sieve only tracks odd numbers: bit i of sieveBits marks 2i+1 as
+// composite. Values below sieveEnd have been sieved.
+var (
+	sieveBits []uint64
+	sieveEnd  int
+)
+
+// millerRabinBases make Miller-Rabin exact for every 64-bit value
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is prime. Small values are answered from a
+// cached segmented sieve, larger ones by deterministic Miller-Rabin.
+func IsPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	if n%2 == 0 {
+		return n == 2
+	}
+	if n < sieveLimit {
+		for sieveEnd <= n {
+			extendSieve()
+		}
+		return !sieveComposite(n)
+	}
+	return millerRabin(uint64(n))
+}
+
+// ProbablyPrime tests an arbitrary precision value. Values that fit in an
+// int are answered exactly; larger ones are wrong with probability at most
+// 4^-rounds.
+func ProbablyPrime(n *big.Int, rounds int) bool {
+	if n.IsInt64() {
+		return IsPrime(int(n.Int64()))
+	}
+	return n.ProbablyPrime(rounds)
+}
+
+// extendSieve sieves the next segment of sieveSegment values
+func extendSieve() {
+	lo, hi := sieveEnd, sieveEnd+sieveSegment
+	sieveBits = append(sieveBits, make([]uint64, sieveSegment/128)...)
+
+	// Primes up to sqrt(hi) are already known, either from earlier segments
+	// or, in the first one, from being reached before their multiples
+	for p := 3; p*p < hi; p += 2 {
+		if sieveComposite(p) {
+			continue
+		}
+		start := max(p*p, (lo+p-1)/p*p)
+		if start%2 == 0 {
+			start += p
+		}
+		for m := start; m < hi; m += 2 * p {
+			i := m / 2
+			sieveBits[i/64] |= 1 << (i % 64)
+		}
+	}
+	sieveEnd = hi
+}
+
+func sieveComposite(n int) bool {
+	i := n / 2
+	return sieveBits[i/64]>>(i%64)&1 == 1
+}
+
+// millerRabin tests an odd n > 2
+func millerRabin(n uint64) bool {
+	d := n - 1
+	s := bits.TrailingZeros64(d)
+	d >>= s
+
+	for _, a := range millerRabinBases {
+		if a%n == 0 {
+			continue
+		}
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for r := 1; r < s; r++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+func powMod(base, exp, m uint64) uint64 {
+	result := uint64(1)
+	base %= m
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, m)
+		}
+		base = mulMod(base, base, m)
+	}
+	return result
+}
+
+// pollardRho finds a nontrivial factor of an odd composite n using Brent's
+// cycle detection, retrying with a new polynomial when a run fails
+func pollardRho(n uint64) uint64 {
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return (mulMod(x, x, n) + c) % n }
+
+		y, r, q := uint64(2), 1, uint64(1)
+		var x, ys uint64
+		g := uint64(1)
+		const batch = 128
+		for g == 1 {
+			x = y
+			for i := 0; i < r; i++ {
+				y = f(y)
+			}
+			for k := 0; k < r && g == 1; k += batch {
+				ys = y
+				for i := 0; i < min(batch, r-k); i++ {
+					y = f(y)
+					q = mulMod(q, absDiff(x, y), n)
+				}
+				g = gcdUint(q, n)
+			}
+			r *= 2
+		}
+
+		// The batched product overshot; step back one value at a time
+		if g == n {
+			for {
+				ys = f(ys)
+				g = gcdUint(absDiff(x, ys), n)
+				if g > 1 {
+					break
+				}
+			}
+		}
+		if g != n {
+			return g
+		}
+	}
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func gcdUint(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
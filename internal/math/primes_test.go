@@ -0,0 +1,135 @@
+package math
+
+import (
+	stdmath "math"
+	"slices"
+	"testing"
+)
+
+// trialDivision is the slow reference IsPrime is checked against
+func trialDivision(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIsPrime(t *testing.T) {
+	tests := []struct {
+		n    int
+		want bool
+	}{
+		{-7, false},
+		{0, false},
+		{1, false},
+		{2, true},
+		{3, true},
+		{4, false},
+		{561, false}, // Carmichael number
+		{7919, true},
+		{sieveLimit - 3, true},
+		{sieveLimit + 43, true},
+		{1<<31 - 1, true},
+		{3215031751, false}, // strong pseudoprime to bases 2, 3, 5 and 7
+		{1000003 * 1000033, false},
+		{1<<61 - 1, true},
+		{stdmath.MaxInt - 24, true}, // largest prime below 2^63
+		{stdmath.MaxInt, false},
+	}
+	for _, tt := range tests {
+		if got := IsPrime(tt.n); got != tt.want {
+			t.Errorf("IsPrime(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestIsPrimeCount(t *testing.T) {
+	count := 0
+	for n := 0; n < 2_000_000; n++ {
+		if IsPrime(n) {
+			count++
+		}
+	}
+	if count != 148933 {
+		t.Errorf("found %d primes below 2*10^6, want 148933", count)
+	}
+}
+
+func TestIsPrimeAroundSieveLimit(t *testing.T) {
+	for n := sieveLimit - 2000; n < sieveLimit+2000; n++ {
+		if got, want := IsPrime(n), trialDivision(n); got != want {
+			t.Errorf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestFactorize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []PrimePower
+	}{
+		{0, nil},
+		{1, nil},
+		{-1, nil},
+		{12, []PrimePower{{2, 2}, {3, 1}}},
+		{-12, []PrimePower{{2, 2}, {3, 1}}},
+		{997, []PrimePower{{997, 1}}},
+		{600851475143, []PrimePower{{71, 1}, {839, 1}, {1471, 1}, {6857, 1}}},
+		{1000003 * 1000033, []PrimePower{{1000003, 1}, {1000033, 1}}},
+		{1<<61 - 1, []PrimePower{{1<<61 - 1, 1}}},
+		{stdmath.MinInt, []PrimePower{{2, 63}}},
+	}
+	for _, tt := range tests {
+		if got := Factorize(tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("Factorize(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFactorizeMultipliesBack(t *testing.T) {
+	for n := 2; n < 20000; n++ {
+		product := 1
+		for _, f := range Factorize(n) {
+			if !IsPrime(f.Prime) {
+				t.Fatalf("Factorize(%d) has non-prime factor %d", n, f.Prime)
+			}
+			for i := 0; i < f.Exp; i++ {
+				product *= f.Prime
+			}
+		}
+		if product != n {
+			t.Fatalf("factors of %d multiply to %d", n, product)
+		}
+	}
+}
+
+func BenchmarkIsPrimeSieve(b *testing.B) {
+	IsPrime(sieveLimit - 1) // sieve the whole range up front
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		IsPrime(1 + 2*(i%(sieveLimit/2)))
+	}
+}
+
+func BenchmarkIsPrimeMillerRabin(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsPrime(1<<61 - 1 - 2*(i%1000))
+	}
+}
+
+func BenchmarkFactorize(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Factorize(1000003 * 1000033)
+	}
+}
+
+func BenchmarkFactorizeSmooth(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Factorize(600851475143)
+	}
+}
@@ -0,0 +1,102 @@
+package math
+
+import (
+	stdmath "math"
+	"math/rand"
+	"sort"
+)
+
+// Distribution draws integers from a seeded random source.
+// Param is the single tunable of the distribution, see ParamName.
+type Distribution struct {
+	ID        string
+	Name      string
+	ParamName string
+	MinParam  int
+	Sample    func(rng *rand.Rand, param int) int
+}
+
+// Distributions lists every distribution random sources can draw from
+var Distributions = []Distribution{
+	{ID: "uniform", Name: "Uniform 1..n", ParamName: "n", MinParam: 1, Sample: UniformInt},
+	{ID: "geometric", Name: "Geometric", ParamName: "p%", MinParam: 1, Sample: Geometric},
+	{ID: "poisson", Name: "Poisson", ParamName: "lambda", MinParam: 1, Sample: Poisson},
+	{ID: "prime_below", Name: "Primes below n", ParamName: "n", MinParam: 3, Sample: RandomPrimeBelow},
+}
+
+// DistributionByID looks up a distribution by its id
+func DistributionByID(id string) (Distribution, bool) {
+	for _, dist := range Distributions {
+		if dist.ID == id {
+			return dist, true
+		}
+	}
+	return Distribution{}, false
+}
+
+// UniformInt draws uniformly from 1..n
+func UniformInt(rng *rand.Rand, n int) int {
+	if n < 1 {
+		return 1
+	}
+	return rng.Intn(n) + 1
+}
+
+// Geometric counts the trials up to and including the first success,
+// where each trial succeeds with percent/100 probability
+func Geometric(rng *rand.Rand, percent int) int {
+	if percent >= 100 {
+		return 1
+	}
+	if percent < 1 {
+		percent = 1
+	}
+	p := float64(percent) / 100
+	// Inverse transform: ceil(ln(U) / ln(1-p)) with U in (0, 1]
+	u := 1 - rng.Float64()
+	return int(stdmath.Ceil(stdmath.Log(u)/stdmath.Log(1-p))) + boolToInt(u == 1)
+}
+
+// Poisson draws from a Poisson distribution with mean lambda
+func Poisson(rng *rand.Rand, lambda int) int {
+	// Knuth's method underflows for large means, so split lambda into
+	// chunks; a sum of independent Poisson draws is Poisson again
+	const chunk = 30
+	total := 0
+	for remaining := lambda; remaining > 0; remaining -= chunk {
+		mean := float64(min(remaining, chunk))
+		limit := stdmath.Exp(-mean)
+		product := rng.Float64()
+		for product > limit {
+			total++
+			product *= rng.Float64()
+		}
+	}
+	return total
+}
+
+// RandomPrimeBelow draws uniformly from the primes smaller than n
+func RandomPrimeBelow(rng *rand.Rand, n int) int {
+	count := PrimeCountBelow(n)
+	if count == 0 {
+		return 2
+	}
+	p, _ := NthPrime(rng.Intn(count))
+	return p
+}
+
+// PrimeCountBelow returns how many primes are smaller than n
+func PrimeCountBelow(n int) int {
+	// Grow the cache past n, then binary search it
+	for primeCache[len(primeCache)-1] < n {
+		NthPrime(len(primeCache))
+	}
+	return sort.SearchInts(primeCache, n)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
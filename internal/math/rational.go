@@ -0,0 +1,118 @@
+package math
+
+import "fmt"
+
+// Rational is an exact fraction Num/Den, always reduced with Den > 0
+type Rational struct {
+	Num, Den int
+}
+
+// NewRational reduces num/den, failing for a zero denominator or overflow
+func NewRational(num, den int) (Rational, bool) {
+	if den == 0 {
+		return Rational{}, false
+	}
+	if den < 0 {
+		var okNum, okDen bool
+		num, okNum = SubChecked(0, num)
+		den, okDen = SubChecked(0, den)
+		if !okNum || !okDen {
+			return Rational{}, false
+		}
+	}
+	g := GCD(num, den)
+	return Rational{Num: num / g, Den: den / g}, true
+}
+
+// Integer returns n as a rational
+func Integer(n int) Rational {
+	return Rational{Num: n, Den: 1}
+}
+
+// GCD returns the greatest common divisor of |a| and |b|, or 1 if both are 0
+func GCD(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return 1
+	}
+	return a
+}
+
+// IsInteger reports whether the rational is a whole number
+func (r Rational) IsInteger() bool {
+	return r.Den == 1
+}
+
+// IsProper reports whether the rational is a fraction strictly between -1 and 1
+func (r Rational) IsProper() bool {
+	return !r.IsInteger() && r.Num > -r.Den && r.Num < r.Den
+}
+
+// Add returns r+o
+func (r Rational) Add(o Rational) (Rational, bool) {
+	left, ok1 := MulChecked(r.Num, o.Den)
+	right, ok2 := MulChecked(o.Num, r.Den)
+	den, ok3 := MulChecked(r.Den, o.Den)
+	if !ok1 || !ok2 || !ok3 {
+		return Rational{}, false
+	}
+	num, ok := AddChecked(left, right)
+	if !ok {
+		return Rational{}, false
+	}
+	return NewRational(num, den)
+}
+
+// Sub returns r-o
+func (r Rational) Sub(o Rational) (Rational, bool) {
+	neg, ok := SubChecked(0, o.Num)
+	if !ok {
+		return Rational{}, false
+	}
+	return r.Add(Rational{Num: neg, Den: o.Den})
+}
+
+// Mul returns r*o
+func (r Rational) Mul(o Rational) (Rational, bool) {
+	// Cross-reduce first to keep intermediate products small
+	g1 := GCD(r.Num, o.Den)
+	g2 := GCD(o.Num, r.Den)
+	num, ok1 := MulChecked(r.Num/g1, o.Num/g2)
+	den, ok2 := MulChecked(r.Den/g2, o.Den/g1)
+	if !ok1 || !ok2 {
+		return Rational{}, false
+	}
+	return NewRational(num, den)
+}
+
+// Div returns r/o, failing when o is zero
+func (r Rational) Div(o Rational) (Rational, bool) {
+	if o.Num == 0 {
+		return Rational{}, false
+	}
+	return r.Mul(Rational{Num: o.Den, Den: o.Num})
+}
+
+// Floor returns the largest integer not above r
+func (r Rational) Floor() int {
+	q := r.Num / r.Den
+	if r.Num%r.Den != 0 && r.Num < 0 {
+		q--
+	}
+	return q
+}
+
+func (r Rational) String() string {
+	if r.IsInteger() {
+		return fmt.Sprintf("%d", r.Num)
+	}
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
@@ -0,0 +1,70 @@
+package math
+
+import (
+	stdmath "math"
+	"math/bits"
+)
+
+// IntRoot returns the floor of the k-th root of n and whether n is an exact k-th power.
+// Negative n only has a root for odd k; it fails for even k.
+func IntRoot(n, k int) (int, bool, bool) {
+	if k < 1 {
+		return 0, false, false
+	}
+	if k == 1 {
+		return n, true, true
+	}
+	if n < 0 {
+		if k%2 == 0 {
+			return 0, false, false
+		}
+		if n == stdmath.MinInt {
+			// -n overflows; -2^63 is an exact k-th power when k divides 63
+			if 63%k == 0 {
+				return -(1 << (63 / k)), true, true
+			}
+			r, _, _ := IntRoot(stdmath.MaxInt, k)
+			return -(r + 1), false, true
+		}
+		r, exact, _ := IntRoot(-n, k)
+		if !exact {
+			// floor of a negative root rounds away from zero
+			r++
+		}
+		return -r, exact, true
+	}
+	if n < 2 {
+		return n, true, true
+	}
+
+	// Start from the float estimate and correct rounding errors
+	r := int(stdmath.Pow(float64(n), 1/float64(k)))
+	for r > 0 && !powAtMost(r, k, n) {
+		r--
+	}
+	for powAtMost(r+1, k, n) {
+		r++
+	}
+
+	p, _ := powChecked(r, k)
+	return r, p == n, true
+}
+
+// powAtMost reports whether r^k <= n without overflowing
+func powAtMost(r, k, n int) bool {
+	p, ok := powChecked(r, k)
+	return ok && p <= n
+}
+
+// powChecked computes r^k for non-negative r, reporting overflow
+func powChecked(r, k int) (int, bool) {
+	result := uint64(1)
+	for i := 0; i < k; i++ {
+		hi, lo := bits.Mul64(result, uint64(r))
+		if hi != 0 || lo > stdmath.MaxInt {
+			return 0, false
+		}
+		result = lo
+	}
+	return int(result), true
+}
@@ -1 +1,551 @@
-package systems
\ No newline at end of file
+package systems
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"math"
+	"os"
+
+	"github.com/Sanjar0126/math-factory/internal/entities"
+	nmath "github.com/Sanjar0126/math-factory/internal/math"
+)
+
+// Salts keep the random draws for different generation decisions on the
+// same tile independent of each other
+const (
+	saltPatch uint64 = iota + 1
+	saltPatchX
+	saltPatchY
+	saltPatchRadius
+	saltPatchEdge
+	saltMagnitude
+	saltInfinite
+	saltZone
+	saltZoneX
+	saltZoneY
+	saltBandAngle
+	saltTerrain
+	saltTerrainX
+	saltTerrainY
+	saltTerrainType
+	saltTerrainSize
+	saltTerrainAngle
+	saltTerrainEdge
+)
+
+// GenerationConfig tunes how ore patches, zones and terrain are laid out.
+// The world is split into square cells of PatchSpacing tiles, each holding
+// at most one patch, into larger cells of ZoneSpacing tiles that each seed
+// one zone region, and into cells of TerrainSpacing tiles that each hold at
+// most one terrain feature.
+type GenerationConfig struct {
+	PatchSpacing int     `json:"patch_spacing"`
+	PatchChance  float64 `json:"patch_chance"` // probability that a cell holds a patch
+	MinRadius    float64 `json:"min_radius"`   // patch radius range, in tiles
+	MaxRadius    float64 `json:"max_radius"`
+	Richness     int     `json:"richness"`      // ore in a patch's center tile, falling off towards its edge
+	InfiniteCore float64 `json:"infinite_core"` // fraction of the radius that is infinite in infinite patches
+
+	ZoneSpacing int     `json:"zone_spacing"`
+	SpawnRadius float64 `json:"spawn_radius"` // plains are guaranteed within this distance of the Core
+	BandScale   float64 `json:"band_scale"`   // Fibonacci bands sit at BandScale*F(k) tiles from the origin
+	RingScale   float64 `json:"ring_scale"`   // square rings sit at RingScale*k^2 tiles from the origin
+
+	TerrainSpacing   int     `json:"terrain_spacing"`
+	TerrainChance    float64 `json:"terrain_chance"`     // probability that a cell holds a terrain feature
+	TerrainMinRadius float64 `json:"terrain_min_radius"` // radius range of void and rough blobs, in tiles
+	TerrainMaxRadius float64 `json:"terrain_max_radius"`
+	RiftLength       float64 `json:"rift_length"` // half the length of an infinity rift, in tiles
+}
+
+// DefaultGenerationConfig is the layout of new worlds
+var DefaultGenerationConfig = GenerationConfig{
+	PatchSpacing: 9,
+	PatchChance:  0.7,
+	MinRadius:    1.5,
+	MaxRadius:    3.5,
+	Richness:     2000,
+	InfiniteCore: 0.35,
+
+	ZoneSpacing: 48,
+	SpawnRadius: 16,
+	BandScale:   3,
+	RingScale:   4,
+
+	TerrainSpacing:   14,
+	TerrainChance:    0.45,
+	TerrainMinRadius: 1.5,
+	TerrainMaxRadius: 3,
+	RiftLength:       5,
+}
+
+// LoadGenerationConfig reads a generation config from a JSON file. Fields
+// the file leaves out keep their DefaultGenerationConfig values, and a
+// missing file gives the defaults.
+func LoadGenerationConfig(file string) (GenerationConfig, error) {
+	config := DefaultGenerationConfig
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: invalid JSON: %w", file, err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("%s: %w", file, err)
+	}
+	return config, nil
+}
+
+// validate checks that a config describes a layout that can be generated
+func (c GenerationConfig) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.PatchSpacing <= 0 || c.ZoneSpacing <= 0 || c.TerrainSpacing <= 0 {
+		fail("\"patch_spacing\", \"zone_spacing\" and \"terrain_spacing\" must be positive")
+	}
+	if c.PatchChance < 0 || c.PatchChance > 1 || c.TerrainChance < 0 || c.TerrainChance > 1 {
+		fail("\"patch_chance\" and \"terrain_chance\" must be between 0 and 1")
+	}
+	if c.MinRadius <= 0 || c.MaxRadius < c.MinRadius {
+		fail("patch radii must satisfy 0 < \"min_radius\" <= \"max_radius\"")
+	}
+	if c.TerrainMinRadius <= 0 || c.TerrainMaxRadius < c.TerrainMinRadius {
+		fail("terrain radii must satisfy 0 < \"terrain_min_radius\" <= \"terrain_max_radius\"")
+	}
+	// Only neighbouring cells are searched, so features must fit in one cell
+	if c.MaxRadius*1.2 > float64(c.PatchSpacing) {
+		fail("\"max_radius\" may be at most \"patch_spacing\"/1.2")
+	}
+	if max(c.TerrainMaxRadius*1.2, c.RiftLength) > float64(c.TerrainSpacing) {
+		fail("\"terrain_max_radius\" may be at most \"terrain_spacing\"/1.2, and \"rift_length\" at most \"terrain_spacing\"")
+	}
+	if c.Richness <= 0 {
+		fail("\"richness\" must be positive, got %d", c.Richness)
+	}
+	if c.InfiniteCore < 0 || c.InfiniteCore > 1 {
+		fail("\"infinite_core\" must be between 0 and 1")
+	}
+	if c.SpawnRadius < 0 || c.BandScale <= 0 || c.RingScale <= 0 || c.RiftLength <= 0 {
+		fail("\"spawn_radius\" must not be negative, and the band, ring and rift sizes must be positive")
+	}
+	return errors.Join(errs...)
+}
+
+// WorldMode is how a world lays out its deposits
+type WorldMode int
+
+const (
+	// ModePatches scatters ore patches over themed zones
+	ModePatches WorldMode = iota
+	// ModeUlam numbers every tile along an Ulam spiral around the Core,
+	// so primes line up along its diagonals
+	ModeUlam
+)
+
+// worldModes lists every mode in the order F3 cycles through them
+var worldModes = []WorldMode{ModePatches, ModeUlam}
+
+// Name returns the name of the mode, as accepted by ParseWorldMode
+func (m WorldMode) Name() string {
+	switch m {
+	case ModeUlam:
+		return "ulam"
+	default:
+		return "patches"
+	}
+}
+
+// Next returns the mode after m
+func (m WorldMode) Next() WorldMode {
+	return worldModes[(int(m)+1)%len(worldModes)]
+}
+
+// ParseWorldMode looks up a mode by name
+func ParseWorldMode(name string) (WorldMode, error) {
+	for _, mode := range worldModes {
+		if mode.Name() == name {
+			return mode, nil
+		}
+	}
+	return ModePatches, fmt.Errorf("unknown world mode %q", name)
+}
+
+// ZoneType is a themed region of the map with its own value distribution
+type ZoneType int
+
+const (
+	ZonePlains ZoneType = iota
+	ZonePrimeField
+	ZoneFibonacciBand
+	ZonePowersOfTwo
+	ZoneSquareRing
+	ZoneCompositeWaste
+)
+
+// regionZones are the zones Voronoi regions are drawn from; bands and rings
+// are laid over them
+var regionZones = []ZoneType{ZonePlains, ZonePlains, ZonePrimeField, ZonePowersOfTwo, ZoneCompositeWaste}
+
+// Name returns the display name of the zone
+func (z ZoneType) Name() string {
+	switch z {
+	case ZonePrimeField:
+		return "prime field"
+	case ZoneFibonacciBand:
+		return "Fibonacci band"
+	case ZonePowersOfTwo:
+		return "powers-of-two cluster"
+	case ZoneSquareRing:
+		return "perfect-square ring"
+	case ZoneCompositeWaste:
+		return "composite wasteland"
+	default:
+		return "plains"
+	}
+}
+
+// Tint returns the background color of the zone's tiles
+func (z ZoneType) Tint() color.RGBA {
+	switch z {
+	case ZonePrimeField:
+		return color.RGBA{30, 70, 35, 90}
+	case ZoneFibonacciBand:
+		return color.RGBA{80, 60, 20, 90}
+	case ZonePowersOfTwo:
+		return color.RGBA{25, 50, 85, 90}
+	case ZoneSquareRing:
+		return color.RGBA{60, 35, 75, 90}
+	case ZoneCompositeWaste:
+		return color.RGBA{70, 45, 35, 90}
+	default:
+		return color.RGBA{0, 0, 0, 0}
+	}
+}
+
+// WorldGen decides which zone each tile belongs to and which deposit it
+// holds. Every decision is drawn from a hash of the seed and the position,
+// so a seed always reproduces the same map regardless of generation order.
+type WorldGen struct {
+	Seed      int64
+	Mode      WorldMode
+	Config    GenerationConfig
+	rand      nmath.HashRand
+	bandAngle float64
+}
+
+// NewWorldGen creates the generator of the world with the given seed and mode
+func NewWorldGen(seed int64, mode WorldMode, config GenerationConfig) *WorldGen {
+	rand := nmath.HashRand{Seed: uint64(seed)}
+	return &WorldGen{
+		Seed:      seed,
+		Mode:      mode,
+		Config:    config,
+		rand:      rand,
+		bandAngle: math.Pi * rand.Float64(0, 0, saltBandAngle),
+	}
+}
+
+// DepositAt returns the deposit generated at a tile, or nil if it has none
+func (g *WorldGen) DepositAt(x, y int) *entities.NumberDeposit {
+	if g.Mode == ModeUlam {
+		return g.ulamDepositAt(x, y)
+	}
+	if !g.shouldGenerateDeposit(x, y) {
+		return nil
+	}
+	patch, distance, ok := g.patchAt(x, y)
+	if !ok {
+		return nil
+	}
+
+	// Every tile of a patch shares the value and zone of its center, except
+	// that tiles on an axis hold zero
+	value := g.generateNumberForPosition(patch.CenterX, patch.CenterY)
+	if x == 0 || y == 0 {
+		value = 0
+	}
+	infinite := distance <= g.Config.InfiniteCore && g.shouldBeInfinite(patch.CenterX, patch.CenterY, value)
+	deposit := entities.NewNumberDeposit(x, y, value, infinite)
+	if !infinite {
+		// Tiles near the center are richer
+		deposit.RemainingOre = max(int(float64(g.Config.Richness)*(1-distance)), 50)
+	}
+	return deposit
+}
+
+// ulamDepositAt returns the deposit of a tile in Ulam mode, which holds the
+// tile's number on the spiral. Every tile has one; all but the primes are
+// faded so the prime diagonals stand out.
+func (g *WorldGen) ulamDepositAt(x, y int) *entities.NumberDeposit {
+	// The spiral turns with y pointing up the screen
+	value, ok := nmath.UlamIndex(x, -y)
+	if !ok {
+		return nil
+	}
+	deposit := entities.NewNumberDeposit(x, y, value, g.shouldBeInfinite(x, y, value))
+	deposit.IsFaded = !deposit.Tags.Has(entities.TagPrime)
+	return deposit
+}
+
+// ZoneAt returns the zone a tile belongs to. Square rings take precedence
+// over Fibonacci bands, which take precedence over the Voronoi regions
+// underneath. The area around the Core is always plains, and so is the
+// whole of an Ulam world.
+func (g *WorldGen) ZoneAt(x, y int) ZoneType {
+	distance := math.Hypot(float64(x), float64(y))
+	switch {
+	case g.Mode == ModeUlam || distance < g.Config.SpawnRadius:
+		return ZonePlains
+	case g.onSquareRing(distance):
+		return ZoneSquareRing
+	case g.onFibonacciBand(x, y):
+		return ZoneFibonacciBand
+	}
+	return g.regionAt(x, y)
+}
+
+// onSquareRing reports whether a distance from the origin lies within a
+// tile of a ring at RingScale*k^2
+func (g *WorldGen) onSquareRing(distance float64) bool {
+	k := math.Round(math.Sqrt(distance / g.Config.RingScale))
+	return math.Abs(distance-g.Config.RingScale*k*k) < 1
+}
+
+// onFibonacciBand reports whether a tile lies within a tile and a half of a
+// straight band at BandScale*F(k) from the origin. Bands run in a direction
+// picked by the seed.
+func (g *WorldGen) onFibonacciBand(x, y int) bool {
+	offset := math.Abs(float64(x)*math.Cos(g.bandAngle) + float64(y)*math.Sin(g.bandAngle))
+	for k := 4; ; k++ {
+		f, ok := nmath.Fibonacci(k)
+		if !ok {
+			return false
+		}
+		band := g.Config.BandScale * float64(f)
+		if math.Abs(offset-band) < 1.5 {
+			return true
+		}
+		if band > offset {
+			return false
+		}
+	}
+}
+
+// regionAt returns the zone of the Voronoi region around the nearest zone
+// center. Each zone cell holds one center, jittered inside the cell.
+func (g *WorldGen) regionAt(x, y int) ZoneType {
+	spacing := g.Config.ZoneSpacing
+	cellX, cellY := floorDiv(x, spacing), floorDiv(y, spacing)
+
+	zone := ZonePlains
+	best := math.Inf(1)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			cx, cy := cellX+dx, cellY+dy
+			centerX := cx*spacing + g.rand.Intn(cx, cy, saltZoneX, spacing)
+			centerY := cy*spacing + g.rand.Intn(cx, cy, saltZoneY, spacing)
+			if d := math.Hypot(float64(x-centerX), float64(y-centerY)); d < best {
+				best = d
+				zone = regionZones[g.rand.Intn(cx, cy, saltZone, len(regionZones))]
+			}
+		}
+	}
+	return zone
+}
+
+// orePatch is a roughly round cluster of deposits around a center tile
+type orePatch struct {
+	CenterX, CenterY int
+	Radius           float64
+}
+
+// patchInCell returns the patch of a generation cell, if it has one. Its
+// center is jittered inside the cell so patches do not line up, and kept
+// off the axes, where it would make the whole patch zero.
+func (g *WorldGen) patchInCell(cellX, cellY int) (orePatch, bool) {
+	cfg := g.Config
+	if g.rand.Float64(cellX, cellY, saltPatch) >= cfg.PatchChance {
+		return orePatch{}, false
+	}
+	radius := cfg.MinRadius + (cfg.MaxRadius-cfg.MinRadius)*g.rand.Float64(cellX, cellY, saltPatchRadius)
+	return orePatch{
+		CenterX: offAxis(cellX*cfg.PatchSpacing + g.rand.Intn(cellX, cellY, saltPatchX, cfg.PatchSpacing)),
+		CenterY: offAxis(cellY*cfg.PatchSpacing + g.rand.Intn(cellX, cellY, saltPatchY, cfg.PatchSpacing)),
+		Radius:  radius,
+	}, true
+}
+
+// offAxis moves a coordinate of 0 to 1
+func offAxis(coordinate int) int {
+	if coordinate == 0 {
+		return 1
+	}
+	return coordinate
+}
+
+// patchAt finds the nearest patch covering a tile and how far the tile is
+// from its center, from 0 at the center to 1 at the edge. Edges are
+// roughened per tile so patches are not perfect discs.
+func (g *WorldGen) patchAt(x, y int) (orePatch, float64, bool) {
+	spacing := g.Config.PatchSpacing
+	cellX, cellY := floorDiv(x, spacing), floorDiv(y, spacing)
+	roughness := 0.8 + 0.4*g.rand.Float64(x, y, saltPatchEdge)
+
+	var best orePatch
+	bestDistance, found := 0.0, false
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			patch, ok := g.patchInCell(cellX+dx, cellY+dy)
+			if !ok {
+				continue
+			}
+			distance := math.Hypot(float64(x-patch.CenterX), float64(y-patch.CenterY)) / (patch.Radius * roughness)
+			if distance <= 1 && (!found || distance < bestDistance) {
+				best, bestDistance, found = patch, distance, true
+			}
+		}
+	}
+	return best, bestDistance, found
+}
+
+// shouldGenerateDeposit keeps the area around the Core clear
+func (g *WorldGen) shouldGenerateDeposit(x, y int) bool {
+	return x < -1 || x > 2 || y < -1 || y > 2
+}
+
+// generateNumberForPosition generates an appropriate number for the given position.
+// Deposits on the axes hold zero and deposits in the negative quadrant are negated.
+func (g *WorldGen) generateNumberForPosition(x, y int) int {
+	if x == 0 || y == 0 {
+		return 0
+	}
+	magnitude := g.generateMagnitudeForPosition(x, y)
+	if x < 0 && y < 0 {
+		return -magnitude
+	}
+	return magnitude
+}
+
+// generateMagnitudeForPosition picks a positive value from the distribution
+// of the tile's zone. Values grow with distance from the origin.
+func (g *WorldGen) generateMagnitudeForPosition(x, y int) int {
+	distanceFromOrigin := math.Hypot(float64(x), float64(y))
+	limit := max(int(distanceFromOrigin*50), 20)
+	rng := g.rand.Stream(x, y, saltMagnitude)
+
+	switch g.ZoneAt(x, y) {
+	case ZonePrimeField:
+		return generatePrimeInRange(2, limit, &rng)
+	case ZoneFibonacciBand:
+		// F(3) = 2 onwards, skipping the repeated ones
+		return pickTerm(nmath.Fibonacci, 3, limit, &rng)
+	case ZonePowersOfTwo:
+		return pickTerm(nmath.PowerOfTwo, 1, limit, &rng)
+	case ZoneSquareRing:
+		return pickTerm(func(n int) (int, bool) { return nmath.MulChecked(n, n) }, 2, limit, &rng)
+	case ZoneCompositeWaste:
+		return generateCompositeInRange(4, limit, &rng)
+	}
+
+	if distanceFromOrigin < 3 {
+		// Very close to origin: tiny numbers (1-5)
+		return rng.Intn(5) + 1
+	} else if distanceFromOrigin < 8 {
+		// Close to origin: small numbers (1-20)
+		return rng.Intn(20) + 1
+	} else if distanceFromOrigin < 15 {
+		// Medium distance: medium numbers (5-100)
+		return rng.Intn(96) + 5
+	} else if distanceFromOrigin < 25 {
+		// Far distance: larger numbers (20-500)
+		return rng.Intn(481) + 20
+	} else {
+		// Very far: huge numbers and more primes
+		return generatePrimeInRange(50, limit, &rng)
+	}
+}
+
+// shouldBeInfinite determines if a deposit should be infinite
+func (g *WorldGen) shouldBeInfinite(x, y int, value int) bool {
+	// Small chance for infinite deposits, higher for primes
+	baseChance := 0.05
+	if nmath.IsPrime(value) {
+		baseChance = 0.15
+	}
+	return g.rand.Float64(x, y, saltInfinite) < baseChance
+}
+
+// pickTerm draws one of the terms of a sequence from index first onwards
+// that do not exceed limit
+func pickTerm(term func(int) (int, bool), first, limit int, rng *nmath.HashStream) int {
+	last := first
+	for {
+		value, ok := term(last + 1)
+		if !ok || value > limit {
+			break
+		}
+		last++
+	}
+	value, _ := term(first + rng.Intn(last-first+1))
+	return value
+}
+
+// generatePrimeInRange draws a prime from [min, max], falling back to the
+// smallest prime in the range when random draws keep missing
+func generatePrimeInRange(min, max int, rng *nmath.HashStream) int {
+	if min > max || min < 2 {
+		return 2
+	}
+
+	// Try to find a prime in the range
+	for attempts := 0; attempts < 100; attempts++ {
+		candidate := rng.Intn(max-min+1) + min
+		if nmath.IsPrime(candidate) {
+			return candidate
+		}
+	}
+
+	// Fallback: find next prime after min
+	for candidate := min; candidate <= max; candidate++ {
+		if nmath.IsPrime(candidate) {
+			return candidate
+		}
+	}
+	return 2 // Ultimate fallback
+}
+
+// generateCompositeInRange draws a composite from [min, max]; composites
+// are common, so a few draws always suffice in practice
+func generateCompositeInRange(min, max int, rng *nmath.HashStream) int {
+	for attempts := 0; attempts < 100; attempts++ {
+		candidate := rng.Intn(max-min+1) + min
+		if candidate > 1 && !nmath.IsPrime(candidate) {
+			return candidate
+		}
+	}
+	return 4
+}
+
+// floorDiv divides rounding towards negative infinity, so cells left of
+// and above the origin are as wide as the others
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
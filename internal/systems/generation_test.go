@@ -0,0 +1,191 @@
+package systems
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chunkSize matches the chunks the world generates at a time
+const chunkSize = 16
+
+// benchChunkX and benchChunkY pick a chunk well away from the Core, where
+// every zone and terrain kind can appear
+const benchChunkX, benchChunkY = 5, -3
+
+// generateChunk decides the zone, terrain and deposit of every tile of a
+// chunk, as the world does when the camera first reaches it
+func generateChunk(g *WorldGen, chunkX, chunkY int) int {
+	deposits := 0
+	for x := chunkX * chunkSize; x < (chunkX+1)*chunkSize; x++ {
+		for y := chunkY * chunkSize; y < (chunkY+1)*chunkSize; y++ {
+			g.ZoneAt(x, y)
+			if !g.TerrainAt(x, y).Buildable() {
+				continue
+			}
+			if g.DepositAt(x, y) != nil {
+				deposits++
+			}
+		}
+	}
+	return deposits
+}
+
+// layoutChunk makes every generation decision of a chunk without building
+// deposits
+func layoutChunk(g *WorldGen, chunkX, chunkY int) {
+	for x := chunkX * chunkSize; x < (chunkX+1)*chunkSize; x++ {
+		for y := chunkY * chunkSize; y < (chunkY+1)*chunkSize; y++ {
+			g.ZoneAt(x, y)
+			g.TerrainAt(x, y)
+			if patch, _, ok := g.patchAt(x, y); ok {
+				g.generateNumberForPosition(patch.CenterX, patch.CenterY)
+			}
+		}
+	}
+}
+
+func TestChunkLayoutDoesNotAllocate(t *testing.T) {
+	g := NewWorldGen(1, ModePatches, DefaultGenerationConfig)
+	allocs := testing.AllocsPerRun(10, func() {
+		layoutChunk(g, benchChunkX, benchChunkY)
+	})
+	if allocs != 0 {
+		t.Errorf("laying out a chunk allocated %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkGenerateChunk(b *testing.B) {
+	g := NewWorldGen(1, ModePatches, DefaultGenerationConfig)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generateChunk(g, benchChunkX+i%8, benchChunkY)
+	}
+}
+
+func BenchmarkLayoutChunk(b *testing.B) {
+	g := NewWorldGen(1, ModePatches, DefaultGenerationConfig)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		layoutChunk(g, benchChunkX+i%8, benchChunkY)
+	}
+}
+
+func BenchmarkGenerateUlamChunk(b *testing.B) {
+	g := NewWorldGen(1, ModeUlam, DefaultGenerationConfig)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generateChunk(g, benchChunkX+i%8, benchChunkY)
+	}
+}
+
+// testChunks are compared between generators, on both sides of both axes
+var testChunks = [][2]int{{0, 0}, {-1, -1}, {3, -2}, {-4, 5}, {-20, -13}, {17, 9}}
+
+// sameTile reports whether two generators agree on a tile
+func sameTile(a, b *WorldGen, x, y int) bool {
+	if a.ZoneAt(x, y) != b.ZoneAt(x, y) || a.TerrainAt(x, y) != b.TerrainAt(x, y) {
+		return false
+	}
+	da, db := a.DepositAt(x, y), b.DepositAt(x, y)
+	if da == nil || db == nil {
+		return da == db
+	}
+	return *da == *db
+}
+
+func TestSameSeedGivesSameChunks(t *testing.T) {
+	for _, mode := range worldModes {
+		a := NewWorldGen(42, mode, DefaultGenerationConfig)
+		b := NewWorldGen(42, mode, DefaultGenerationConfig)
+
+		// Generate b's chunks in reverse to show order does not matter
+		for i := range testChunks {
+			chunk := testChunks[len(testChunks)-1-i]
+			generateChunk(b, chunk[0], chunk[1])
+		}
+		for _, chunk := range testChunks {
+			for x := chunk[0] * chunkSize; x < (chunk[0]+1)*chunkSize; x++ {
+				for y := chunk[1] * chunkSize; y < (chunk[1]+1)*chunkSize; y++ {
+					if !sameTile(a, b, x, y) {
+						t.Fatalf("%s mode: seed 42 generated tile (%d, %d) differently", mode.Name(), x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDifferentSeedsGiveDifferentMaps(t *testing.T) {
+	a := NewWorldGen(1, ModePatches, DefaultGenerationConfig)
+	b := NewWorldGen(2, ModePatches, DefaultGenerationConfig)
+
+	differing := 0
+	for _, chunk := range testChunks {
+		for x := chunk[0] * chunkSize; x < (chunk[0]+1)*chunkSize; x++ {
+			for y := chunk[1] * chunkSize; y < (chunk[1]+1)*chunkSize; y++ {
+				if !sameTile(a, b, x, y) {
+					differing++
+				}
+			}
+		}
+	}
+	if differing == 0 {
+		t.Error("seeds 1 and 2 generated identical maps")
+	}
+}
+
+func TestLoadGenerationConfig(t *testing.T) {
+	config, err := LoadGenerationConfig("../../data/generation.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != DefaultGenerationConfig {
+		t.Errorf("data/generation.json = %+v, want the defaults %+v", config, DefaultGenerationConfig)
+	}
+
+	config, err = LoadGenerationConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || config != DefaultGenerationConfig {
+		t.Errorf("missing file gave %+v, %v; want the defaults", config, err)
+	}
+
+	tests := map[string]string{
+		`{"patch_chance": 0.2, "richness": 500}`: "",
+		`{"patch_chance": 1.5}`:                  "patch_chance",
+		`{"patch_spacing": 0}`:                   "patch_spacing",
+		`{"max_radius": 20}`:                     "max_radius",
+		`{"patch_size": 3}`:                      "unknown field",
+	}
+	for content, wantErr := range tests {
+		file := filepath.Join(t.TempDir(), "generation.json")
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadGenerationConfig(file)
+		switch {
+		case wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", content, err)
+		case wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)):
+			t.Errorf("%s: got error %v, want one mentioning %q", content, err, wantErr)
+		}
+	}
+}
+
+func TestOnlyAxisTilesHoldZero(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		g := NewWorldGen(seed, ModePatches, DefaultGenerationConfig)
+		for x := -40; x <= 40; x++ {
+			for y := -40; y <= 40; y++ {
+				deposit := g.DepositAt(x, y)
+				if deposit == nil {
+					continue
+				}
+				onAxis := x == 0 || y == 0
+				if zero := deposit.NumberValue == 0; zero != onAxis {
+					t.Fatalf("seed %d: deposit at (%d, %d) holds %d", seed, x, y, deposit.NumberValue)
+				}
+			}
+		}
+	}
+}
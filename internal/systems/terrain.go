@@ -0,0 +1,146 @@
+package systems
+
+import (
+	"image/color"
+	"math"
+)
+
+// TerrainType is the ground a tile is made of. Terrain is ordered from
+// mildest to harshest, so the harshest one wins where features overlap.
+type TerrainType int
+
+const (
+	TerrainOpen TerrainType = iota
+	TerrainRough
+	TerrainInfinityRift
+	TerrainUndefined
+)
+
+// terrainFeatures are the features a terrain cell can hold, rough ground
+// being the most common
+var terrainFeatures = []TerrainType{TerrainRough, TerrainRough, TerrainInfinityRift, TerrainUndefined}
+
+// Name returns the display name of the terrain
+func (t TerrainType) Name() string {
+	switch t {
+	case TerrainRough:
+		return "rough ground"
+	case TerrainInfinityRift:
+		return "infinity rift"
+	case TerrainUndefined:
+		return "undefined void"
+	default:
+		return "open ground"
+	}
+}
+
+// Color returns the fill color of the terrain's tiles
+func (t TerrainType) Color() color.RGBA {
+	switch t {
+	case TerrainRough:
+		return color.RGBA{45, 40, 30, 160}
+	case TerrainInfinityRift:
+		return color.RGBA{45, 10, 70, 255}
+	case TerrainUndefined:
+		return color.RGBA{8, 8, 12, 255}
+	default:
+		return color.RGBA{0, 0, 0, 0}
+	}
+}
+
+// Buildable reports whether buildings can be placed on the terrain
+func (t TerrainType) Buildable() bool {
+	return t == TerrainOpen || t == TerrainRough
+}
+
+// BlocksNumbers reports whether numbers cannot be dropped onto the terrain.
+// Numbers dropped into a rift are not blocked, they fall in and are lost.
+func (t TerrainType) BlocksNumbers() bool {
+	return t == TerrainUndefined
+}
+
+// SwallowsNumbers reports whether numbers dropped onto the terrain are lost
+func (t TerrainType) SwallowsNumbers() bool {
+	return t == TerrainInfinityRift
+}
+
+// Crossable reports whether bridges can carry numbers over the terrain.
+// Rifts can be spanned; the undefined void cannot.
+func (t TerrainType) Crossable() bool {
+	return t == TerrainInfinityRift
+}
+
+// WorkTime returns how many ticks work that takes base ticks on open ground
+// takes on the terrain. Buildings on rough ground run at two thirds speed.
+func (t TerrainType) WorkTime(base int) int {
+	if t == TerrainRough {
+		return base * 3 / 2
+	}
+	return base
+}
+
+// terrainFeature is a blob of rough ground or void, or a straight rift
+// through its center
+type terrainFeature struct {
+	Type             TerrainType
+	CenterX, CenterY int
+	Radius           float64 // blob radius, or half the length of a rift
+	Angle            float64 // direction of a rift
+}
+
+// TerrainAt returns the terrain of a tile. The area around the Core is
+// always open, and so is the whole of an Ulam world.
+func (g *WorldGen) TerrainAt(x, y int) TerrainType {
+	if g.Mode == ModeUlam || math.Hypot(float64(x), float64(y)) < g.Config.SpawnRadius {
+		return TerrainOpen
+	}
+
+	spacing := g.Config.TerrainSpacing
+	cellX, cellY := floorDiv(x, spacing), floorDiv(y, spacing)
+	roughness := 0.8 + 0.4*g.rand.Float64(x, y, saltTerrainEdge)
+
+	terrain := TerrainOpen
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			feature, ok := g.terrainInCell(cellX+dx, cellY+dy)
+			if ok && feature.Type > terrain && feature.covers(x, y, roughness) {
+				terrain = feature.Type
+			}
+		}
+	}
+	return terrain
+}
+
+// terrainInCell returns the terrain feature of a terrain cell, if it has one
+func (g *WorldGen) terrainInCell(cellX, cellY int) (terrainFeature, bool) {
+	cfg := g.Config
+	if g.rand.Float64(cellX, cellY, saltTerrain) >= cfg.TerrainChance {
+		return terrainFeature{}, false
+	}
+
+	feature := terrainFeature{
+		Type:    terrainFeatures[g.rand.Intn(cellX, cellY, saltTerrainType, len(terrainFeatures))],
+		CenterX: cellX*cfg.TerrainSpacing + g.rand.Intn(cellX, cellY, saltTerrainX, cfg.TerrainSpacing),
+		CenterY: cellY*cfg.TerrainSpacing + g.rand.Intn(cellX, cellY, saltTerrainY, cfg.TerrainSpacing),
+		Angle:   math.Pi * g.rand.Float64(cellX, cellY, saltTerrainAngle),
+	}
+	size := g.rand.Float64(cellX, cellY, saltTerrainSize)
+	if feature.Type == TerrainInfinityRift {
+		feature.Radius = cfg.RiftLength * (0.6 + 0.4*size)
+	} else {
+		feature.Radius = cfg.TerrainMinRadius + (cfg.TerrainMaxRadius-cfg.TerrainMinRadius)*size
+	}
+	return feature, true
+}
+
+// covers reports whether a feature covers a tile. Blob edges are roughened
+// per tile like ore patches; rifts are a tile wide and run the whole length.
+func (f terrainFeature) covers(x, y int, roughness float64) bool {
+	dx, dy := float64(x-f.CenterX), float64(y-f.CenterY)
+	if f.Type != TerrainInfinityRift {
+		return math.Hypot(dx, dy) <= f.Radius*roughness
+	}
+	along := dx*math.Cos(f.Angle) + dy*math.Sin(f.Angle)
+	across := -dx*math.Sin(f.Angle) + dy*math.Cos(f.Angle)
+	return math.Abs(along) <= f.Radius && math.Abs(across) < 0.75
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+    "flag"
     "log"
 
     "github.com/hajimehoshi/ebiten/v2"
     "github.com/Sanjar0126/math-factory/internal/game"
+    "github.com/Sanjar0126/math-factory/internal/systems"
 )
 
 const (
@@ -14,7 +16,20 @@ const (
 )
 
 func main() {
-    g := game.NewGame(screenWidth, screenHeight)
+    dataDir := flag.String("data", "data", "directory containing building definitions and the world layout")
+    seed := flag.Int64("seed", 0, "world seed; 0 picks a random world")
+    modeName := flag.String("mode", "patches", "world mode: patches or ulam")
+    flag.Parse()
+
+    mode, err := systems.ParseWorldMode(*modeName)
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    g, err := game.NewGame(screenWidth, screenHeight, *dataDir, *seed, mode)
+    if err != nil {
+        log.Fatal(err)
+    }
 
     ebiten.SetWindowSize(screenWidth, screenHeight)
     ebiten.SetWindowTitle(gameTitle)